@@ -4,41 +4,59 @@ import {describe} from "mocha";
 const path = require("path");
 const wasmTester = require("circom_tester").wasm;
 
-describe("Test linkedMultiQuery.circom", function () {
+// circuit name and testdata directory of the circuit size
+const circuits = [
+    {name: "linkedMultiQuery", dir: "linked"},
+    {name: "linkedMultiQuery5", dir: "linked5"},
+    {name: "linkedMultiQuery3", dir: "linked3"},
+];
 
-    this.timeout(600000);
+circuits.forEach(({name, dir}) => {
+    describe(`Test ${name}.circom`, function () {
 
-    let circuit;
+        this.timeout(600000);
 
-    before(async () => {
-        circuit = await wasmTester(
-            path.join(__dirname, "../../circuits", "linkedMultiQuery.circom"),
-            {
-                output: path.join(__dirname, "circuits", "build"),
-                recompile: true,
-            },
-        );
+        let circuit;
 
-    });
-
-    after(async () => {
-        circuit.release()
-    })
-
-    const basePath = '../../testvectorgen/credentials/linked/testdata/linked'
-    const tests = [
-
-        require(`${basePath}/one_query.json`),
-        require(`${basePath}/two_queries.json`),
+        before(async () => {
+            circuit = await wasmTester(
+                path.join(__dirname, "../../circuits", `${name}.circom`),
+                {
+                    output: path.join(__dirname, "circuits", "build"),
+                    recompile: true,
+                },
+            );
 
-    ];
+        });
 
-    tests.forEach(({ desc, inputs, expOut }) => {
-        it(`${desc}`, async function () {
-            const w = await circuit.calculateWitness(inputs, true);
-            await circuit.assertOut(w, expOut);
-            await circuit.checkConstraints(w);
+        after(async () => {
+            circuit.release()
+        })
+
+        const basePath = `../../testvectorgen/credentials/linked/testdata/${dir}`
+        // linked to credentialAtomicQueryV3 vectors of the same claim
+        const v3BasePath = `../../testvectorgen/credentials/v3/testdata/${dir}`
+        const tests = [
+
+            require(`${basePath}/one_query.json`),
+            require(`${basePath}/two_queries.json`),
+            require(`${basePath}/subject_in_value.json`),
+            require(`${basePath}/self_claim.json`),
+            require(`${basePath}/query_paths.json`),
+            require(`${basePath}/all_queries.json`),
+            require(`${basePath}/non_merklized.json`),
+            require(`${v3BasePath}/non_merklized.json`),
+            require(`${v3BasePath}/merklized.json`),
+
+        ];
+
+        tests.forEach(({ desc, inputs, expOut }) => {
+            it(`${desc}`, async function () {
+                const w = await circuit.calculateWitness(inputs, true);
+                await circuit.assertOut(w, expOut);
+                await circuit.checkConstraints(w);
+            });
         });
-    });
 
+    });
 });
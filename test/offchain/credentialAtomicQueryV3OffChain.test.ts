@@ -44,6 +44,35 @@ describe("Test credentialAtomicQueryV3OffChain.circom", function () {
         require(`${sigBasePath}/noop_operator.json`),
         require(`${sigBasePath}/not_between_operator.json`),
         require(`${sigBasePath}/in_operator.json`),
+        require(`${sigBasePath}/claim_subject_in_value.json`),
+        require(`${sigBasePath}/claim_subject_in_value_merklized.json`),
+        require(`${sigBasePath}/expiration_none.json`),
+        require(`${sigBasePath}/expiration_future.json`),
+        require(`${sigBasePath}/expiration_now.json`),
+        require(`${sigBasePath}/typed_date_gt.json`),
+        require(`${sigBasePath}/typed_date_between.json`),
+        require(`${sigBasePath}/typed_birth_date_lt.json`),
+        require(`${sigBasePath}/typed_string_in.json`),
+        require(`${sigBasePath}/typed_string_nin.json`),
+        require(`${sigBasePath}/nullifier_base.json`),
+        require(`${sigBasePath}/nullifier_other_claim.json`),
+        require(`${sigBasePath}/nullifier_user_genesis.json`),
+        require(`${sigBasePath}/nullifier_other_subject_profile.json`),
+        require(`${sigBasePath}/nullifier_other_session.json`),
+        require(`${sigBasePath}/nullifier_other_verifier.json`),
+        require(`${sigBasePath}/nullifier_subject_genesis.json`),
+        require(`${sigBasePath}/linked_non_merklized.json`),
+        require(`${sigBasePath}/linked_merklized.json`),
+        require(`${sigBasePath}/claim_slot_index_b.json`),
+        require(`${sigBasePath}/claim_slot_value_a.json`),
+        require(`${sigBasePath}/claim_slot_value_b.json`),
+        require(`${sigBasePath}/revocation_proof_inclusion.json`),
+        require(`${sigBasePath}/revocation_proof_non_inclusion_aux.json`),
+        require(`${sigBasePath}/revocation_proof_non_inclusion_empty.json`),
+        require(`${sigBasePath}/claim_path_proof_inclusion.json`),
+        require(`${sigBasePath}/claim_path_proof_non_inclusion_aux.json`),
+        require(`${sigBasePath}/claim_path_proof_non_inclusion_empty.json`),
+        require(`${sigBasePath}/deep_proofs.json`),
 
         // mtp
         require(`${mtpBasePath}/claimIssuedOnProfileID.json`),
@@ -60,6 +89,38 @@ describe("Test credentialAtomicQueryV3OffChain.circom", function () {
         require(`${mtpBasePath}/noop_operator.json`),
         require(`${mtpBasePath}/not_between_operator.json`),
         require(`${mtpBasePath}/in_operator.json`),
+        require(`${mtpBasePath}/claim_subject_in_value.json`),
+        require(`${mtpBasePath}/claim_subject_in_value_merklized.json`),
+        require(`${mtpBasePath}/expiration_none.json`),
+        require(`${mtpBasePath}/expiration_future.json`),
+        require(`${mtpBasePath}/expiration_now.json`),
+        require(`${mtpBasePath}/typed_date_gt.json`),
+        require(`${mtpBasePath}/typed_date_between.json`),
+        require(`${mtpBasePath}/typed_birth_date_lt.json`),
+        require(`${mtpBasePath}/typed_string_in.json`),
+        require(`${mtpBasePath}/typed_string_nin.json`),
+        require(`${mtpBasePath}/onchain_issuer.json`),
+        require(`${mtpBasePath}/onchain_issuer_revoked_without_revocation_check.json`),
+        require(`${mtpBasePath}/onchain_issuer_merklized.json`),
+        require(`${mtpBasePath}/nullifier_base.json`),
+        require(`${mtpBasePath}/nullifier_other_claim.json`),
+        require(`${mtpBasePath}/nullifier_user_genesis.json`),
+        require(`${mtpBasePath}/nullifier_other_subject_profile.json`),
+        require(`${mtpBasePath}/nullifier_other_session.json`),
+        require(`${mtpBasePath}/nullifier_other_verifier.json`),
+        require(`${mtpBasePath}/nullifier_subject_genesis.json`),
+        require(`${mtpBasePath}/linked_non_merklized.json`),
+        require(`${mtpBasePath}/linked_merklized.json`),
+        require(`${mtpBasePath}/claim_slot_index_b.json`),
+        require(`${mtpBasePath}/claim_slot_value_a.json`),
+        require(`${mtpBasePath}/claim_slot_value_b.json`),
+        require(`${mtpBasePath}/revocation_proof_inclusion.json`),
+        require(`${mtpBasePath}/revocation_proof_non_inclusion_aux.json`),
+        require(`${mtpBasePath}/revocation_proof_non_inclusion_empty.json`),
+        require(`${mtpBasePath}/claim_path_proof_inclusion.json`),
+        require(`${mtpBasePath}/claim_path_proof_non_inclusion_aux.json`),
+        require(`${mtpBasePath}/claim_path_proof_non_inclusion_empty.json`),
+        require(`${mtpBasePath}/deep_proofs.json`),
     ];
 
     tests.forEach(({ desc, inputs, expOut }) => {
@@ -88,6 +149,8 @@ describe("Test credentialAtomicQueryV3OffChain.circom", function () {
     const failInTestCase = [
         require(`${sigBasePath}/in_operator_failed_0.json`),
         require(`${mtpBasePath}/in_operator_failed_0.json`),
+        require(`${sigBasePath}/typed_string_eq_failed.json`),
+        require(`${mtpBasePath}/typed_string_eq_failed.json`),
     ];
 
     failInTestCase.forEach(({ desc, inputs, expOut }) => {
@@ -99,4 +162,36 @@ describe("Test credentialAtomicQueryV3OffChain.circom", function () {
             expect(error.message).to.include("Error in template ProcessQueryWithModifiers");
         })
     });
+
+    const failOwnershipTestCase = [
+        require(`${sigBasePath}/self_claim.json`),
+        require(`${mtpBasePath}/self_claim.json`),
+        require(`${sigBasePath}/claim_issued_on_other_identity.json`),
+        require(`${mtpBasePath}/claim_issued_on_other_identity.json`),
+    ];
+
+    failOwnershipTestCase.forEach(({ desc, inputs, expOut }) => {
+        it(`${desc}`, async function () {
+            let error;
+            await circuit.calculateWitness(inputs, true).catch((err) => {
+                error = err;
+            });
+            expect(error.message).to.include("Error in template verifyCredentialSubjectProfile");
+        })
+    });
+
+    const failExpirationTestCase = [
+        require(`${sigBasePath}/expiration_past.json`),
+        require(`${mtpBasePath}/expiration_past.json`),
+    ];
+
+    failExpirationTestCase.forEach(({ desc, inputs, expOut }) => {
+        it(`${desc}`, async function () {
+            let error;
+            await circuit.calculateWitness(inputs, true).catch((err) => {
+                error = err;
+            });
+            expect(error.message).to.include("Error in template verifyExpirationTime");
+        })
+    });
 });
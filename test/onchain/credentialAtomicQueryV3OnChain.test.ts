@@ -44,6 +44,11 @@ describe("Test credentialAtomicQueryV3OnChain.circom", function () {
         require(`${sigBasePath}/jsonld_non_inclusion.json`),
         require(`${sigBasePath}/noop_operator.json`),
         require(`${sigBasePath}/onchainIdentity.json`),
+        require(`${sigBasePath}/claim_subject_in_value.json`),
+        require(`${sigBasePath}/claim_subject_in_value_merklized.json`),
+        require(`${sigBasePath}/claim_slot_index_b.json`),
+        require(`${sigBasePath}/claim_slot_value_a.json`),
+        require(`${sigBasePath}/claim_slot_value_b.json`),
 
         // mtp
         require(`${mtpBasePath}/claimIssuedOnProfileID.json`),
@@ -59,6 +64,11 @@ describe("Test credentialAtomicQueryV3OnChain.circom", function () {
         require(`${mtpBasePath}/revoked_claim_without_revocation_check.json`),
         require(`${mtpBasePath}/noop_operator.json`),
         require(`${mtpBasePath}/onchainIdentity.json`),
+        require(`${mtpBasePath}/claim_subject_in_value.json`),
+        require(`${mtpBasePath}/claim_subject_in_value_merklized.json`),
+        require(`${mtpBasePath}/claim_slot_index_b.json`),
+        require(`${mtpBasePath}/claim_slot_value_a.json`),
+        require(`${mtpBasePath}/claim_slot_value_b.json`),
     ];
 
     tests.forEach(({ desc, inputs, expOut }) => {
@@ -83,4 +93,21 @@ describe("Test credentialAtomicQueryV3OnChain.circom", function () {
             expect(error.message).to.include("Error in template checkClaimNotRevoked");
         })
     });
+
+    const failOwnershipTestCase = [
+        require(`${sigBasePath}/self_claim.json`),
+        require(`${mtpBasePath}/self_claim.json`),
+        require(`${sigBasePath}/claim_issued_on_other_identity.json`),
+        require(`${mtpBasePath}/claim_issued_on_other_identity.json`),
+    ];
+
+    failOwnershipTestCase.forEach(({ desc, inputs, expOut }) => {
+        it(`${desc}`, async function () {
+            let error;
+            await circuit.calculateWitness(inputs, true).catch((err) => {
+                error = err;
+            });
+            expect(error.message).to.include("Error in template verifyCredentialSubjectProfile");
+        })
+    });
 });
@@ -44,6 +44,8 @@ describe("Test credentialAtomicQueryV3Universal.circom", function () {
         require(`${sigBasePath}/noop_operator.json`),
         require(`${sigBasePath}/not_between_operator.json`),
         require(`${sigBasePath}/in_operator.json`),
+        require(`${sigBasePath}/claim_subject_in_value.json`),
+        require(`${sigBasePath}/claim_subject_in_value_merklized.json`),
 
         // mtp
         require(`${mtpBasePath}/claimIssuedOnProfileID.json`),
@@ -60,6 +62,8 @@ describe("Test credentialAtomicQueryV3Universal.circom", function () {
         require(`${mtpBasePath}/noop_operator.json`),
         require(`${mtpBasePath}/not_between_operator.json`),
         require(`${mtpBasePath}/in_operator.json`),
+        require(`${mtpBasePath}/claim_subject_in_value.json`),
+        require(`${mtpBasePath}/claim_subject_in_value_merklized.json`),
     ];
 
     tests.forEach(({ desc, inputs, expOut }) => {
@@ -99,4 +103,21 @@ describe("Test credentialAtomicQueryV3Universal.circom", function () {
             expect(error.message).to.include("Error in template ProcessQueryWithModifiers");
         })
     });
+
+    const failOwnershipTestCase = [
+        require(`${sigBasePath}/self_claim.json`),
+        require(`${mtpBasePath}/self_claim.json`),
+        require(`${sigBasePath}/claim_issued_on_other_identity.json`),
+        require(`${mtpBasePath}/claim_issued_on_other_identity.json`),
+    ];
+
+    failOwnershipTestCase.forEach(({ desc, inputs, expOut }) => {
+        it(`${desc}`, async function () {
+            let error;
+            await circuit.calculateWitness(inputs, true).catch((err) => {
+                error = err;
+            });
+            expect(error.message).to.include("Error in template verifyCredentialSubjectProfile");
+        })
+    });
 });
@@ -98,24 +98,82 @@ func Test_ProfileID(t *testing.T) {
 	generateAuthTestData(t, isUserIDProfile, isUserStateGenesis, isSecondAuthClaim, desc, "userID_profileID")
 }
 
+func Test_DeepProofs(t *testing.T) {
+	generateAuth(t, authTestCase{
+		Desc:       "Ownership true. User state: not-genesis. User trees with fillers. Auth claim and GIST proofs of max depth",
+		FileName:   "deep_proofs",
+		ProofDepth: utils.IdentityTreeLevels - 1,
+		GistDepth:  utils.GistLevels - 1,
+	})
+}
+
+func Test_GistProofCases(t *testing.T) {
+	generateAuth(t, authTestCase{
+		Desc:      "Ownership true. User state: not-genesis. Auth claims total/signedWith/revoked: 1/1/none",
+		FileName:  "gist_proof_inclusion",
+		GistProof: utils.ProofInclusion,
+	})
+	// user of genesis state is not in GIST
+	generateAuth(t, authTestCase{
+		Desc:               "Ownership true. User state: genesis. Auth claims total/signedWith/revoked: 1/1/none",
+		FileName:           "gist_proof_non_inclusion_aux",
+		IsUserStateGenesis: true,
+		GistProof:          utils.ProofNonInclusionAux,
+	})
+	generateAuth(t, authTestCase{
+		Desc:               "Ownership true. User state: genesis. Auth claims total/signedWith/revoked: 1/1/none",
+		FileName:           "gist_proof_non_inclusion_empty",
+		IsUserStateGenesis: true,
+		GistProof:          utils.ProofNonInclusionEmpty,
+	})
+}
+
 func generateAuthTestData(t *testing.T, profile, genesis, isSecondAuthClaim bool, desc, fileName string) {
+	generateAuth(t, authTestCase{
+		Desc:               desc,
+		FileName:           fileName,
+		IsUserIDProfile:    profile,
+		IsUserStateGenesis: genesis,
+		IsSecondAuthClaim:  isSecondAuthClaim,
+	})
+}
+
+// authTestCase describes authV3 test vector
+type authTestCase struct {
+	Desc               string
+	FileName           string
+	IsUserIDProfile    bool
+	IsUserStateGenesis bool
+	IsSecondAuthClaim  bool
+	// ProofDepth and GistDepth extend user tree proofs and GIST proof to the
+	// depth, 0 keeps the trees minimal
+	ProofDepth, GistDepth int
+	// GistProof forces the case of GIST proof, the forced case is added to
+	// the description
+	GistProof utils.ProofCase
+}
 
-	nonce := big.NewInt(0)
+func generateAuth(t *testing.T, tc authTestCase) {
+	desc, fileName := tc.Desc, tc.FileName
+	profile, genesis, isSecondAuthClaim := tc.IsUserIDProfile, tc.IsUserStateGenesis, tc.IsSecondAuthClaim
 
 	challenge := big.NewInt(12345)
 
-	user := utils.NewIdentity(t, userPK)
+	var opts []utils.IdentityOption
+	if tc.ProofDepth > 0 {
+		opts = append(opts, utils.WithFillers(100), utils.WithProofDepth(tc.ProofDepth))
+	}
+	user := utils.NewIdentity(t, userPK, opts...)
 
 	var err error
 
 	userProfile := user.ID
 	if profile {
-		nonce = big.NewInt(10)
-		userProfile, err = core.ProfileID(user.ID, nonce)
-		require.NoError(t, err)
+		userProfile = user.AddProfile(t, utils.UserProfile, big.NewInt(utils.UserProfileNonce))
 	}
+	nonce := user.ProfileNonce(t, userProfile)
 
-	gisTree, err := merkletree.NewMerkleTree(context.Background(), memory.NewMemoryStorage(), 40)
+	gisTree, err := merkletree.NewMerkleTree(context.Background(), memory.NewMemoryStorage(), utils.GistLevels)
 	require.Nil(t, err)
 	gisTree.Add(context.Background(), big.NewInt(1), big.NewInt(1))
 
@@ -143,6 +201,14 @@ func generateAuthTestData(t *testing.T, profile, genesis, isSecondAuthClaim bool
 
 	}
 
+	if tc.GistDepth > 0 {
+		utils.DeepenPath(t, gisTree, user.IDHash(t), tc.GistDepth)
+	}
+	if tc.GistProof != utils.ProofAny {
+		utils.ForceProofCase(t, gisTree, user.IDHash(t), user.State(t), tc.GistProof)
+		desc += ". GIST proof: " + tc.GistProof.String()
+	}
+
 	// user
 	authMTProof := user.AuthMTPStrign(t)
 
@@ -194,6 +260,7 @@ func generateAuthTestData(t *testing.T, profile, genesis, isSecondAuthClaim bool
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(json))
+	utils.SaveSnarkjsFiles(t, "authV3", fileName, string(json))
 }
 
 func TestTre(t *testing.T) {
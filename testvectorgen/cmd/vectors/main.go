@@ -0,0 +1,90 @@
+// Command vectors is a helper tool to review generated test vectors.
+//
+// Usage:
+//
+//	vectors diff <old.json> <new.json>
+//	vectors inspect <vector.json>
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"test/vector"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "diff":
+		err = diff(os.Args[2:])
+	case "inspect":
+		err = inspect(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  vectors diff <old.json> <new.json>")
+	fmt.Fprintln(os.Stderr, "  vectors inspect <vector.json>")
+	os.Exit(2)
+}
+
+func diff(args []string) error {
+	if len(args) != 2 {
+		usage()
+	}
+
+	a, err := vector.Load(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := vector.Load(args[1])
+	if err != nil {
+		return err
+	}
+
+	diffs, err := vector.Diff(a, b)
+	if err != nil {
+		return err
+	}
+
+	err = vector.WriteDiff(os.Stdout, diffs)
+	if err != nil {
+		return err
+	}
+
+	if len(diffs) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func inspect(args []string) error {
+	if len(args) != 1 {
+		usage()
+	}
+
+	td, err := vector.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	ins, err := vector.Inspect(td)
+	if err != nil {
+		return err
+	}
+
+	return vector.WriteInspection(os.Stdout, ins)
+}
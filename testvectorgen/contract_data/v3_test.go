@@ -7,9 +7,10 @@ import (
 	"strconv"
 	"testing"
 
+	"test/query"
 	"test/utils"
 
-	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	core "github.com/iden3/go-iden3-core/v2"
 	"github.com/iden3/go-iden3-crypto/babyjub"
 	"github.com/iden3/go-iden3-crypto/poseidon"
@@ -22,6 +23,7 @@ import (
 
 const (
 	ethAddress = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+	EthPK      = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80" // key of ethAddress, hardhat account #0
 	UserPK     = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69e"
 	IssuerPK   = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69d"
 	timestamp  = "1642074362"
@@ -130,6 +132,21 @@ func Test_Generate_Test_CasesV3(t *testing.T) {
 	generateData(t, "BJJ: Issuer genesis state / user - first state", []*gistData{
 		{userId, userFirstState},
 	}, true, false, true, false, "v3/valid_bjj_user_first_issuer_genesis_v3", verifiable.BJJSignatureProofType, 1)
+
+	// auth disabled users of other hardhat accounts
+	for i, ethPK := range []string{
+		"59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+		"5de4111afa1a4b94908f83103eb1f1706367c2e68ca870fc3fb9a804cdab365a",
+	} {
+		account := strconv.Itoa(i + 1)
+		ethUser := utils.NewEthIdentity(t, ethPK)
+		generateDataWithEthKey(t, "BJJ: Issuer first state / user - genesis state - Auth Disabled. Account #"+account, []*gistData{
+			{issuerId, issuerAuthDisabledFirstState},
+		}, false, false, false, false, "v3/valid_bjj_user_genesis_auth_disabled_account_"+account+"_v3", verifiable.BJJSignatureProofType, 0, ethUser)
+		generateDataWithEthKey(t, "MTP: Issuer first state / user - genesis state - Auth Disabled. Account #"+account, []*gistData{
+			{issuerId, issuerAuthDisabledFirstState},
+		}, false, false, false, false, "v3/valid_mtp_user_genesis_auth_disabled_account_"+account+"_v3", verifiable.Iden3SparseMerkleTreeProofType, 0, ethUser)
+	}
 }
 
 func generateStateTransitionData(t *testing.T, nextState bool, primaryPK, secondaryPK, desc, fileName string, isSubjectIDProfile bool, isEthBased bool) (*big.Int, *big.Int) {
@@ -162,9 +179,7 @@ func generateStateTransitionData(t *testing.T, nextState bool, primaryPK, second
 
 	subjectID := secondaryEntity.ID
 	if isSubjectIDProfile {
-		nonceSubject := big.NewInt(999)
-		subjectID, err = core.ProfileID(secondaryEntity.ID, nonceSubject)
-		require.NoError(t, err)
+		subjectID = secondaryEntity.AddProfile(t, utils.SubjectProfile, big.NewInt(utils.SubjectProfileNonce))
 	}
 
 	_, secondaryEntityClaim := utils.DefaultJSONNormalUserClaim(t, subjectID)
@@ -235,11 +250,22 @@ func generateStateTransitionData(t *testing.T, nextState bool, primaryPK, second
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(json_))
+	utils.SaveSnarkjsFiles(t, "stateTransitionV3", fileName, string(json_))
+	utils.SaveContractFixture(t, "stateTransitionV3", "common-data", fileName, string(json_))
 
 	return primaryEntity.ID.BigInt(), primaryEntity.State(t)
 }
 
 func generateData(t *testing.T, desc string, gistData []*gistData, userFirstState bool, userSecondState bool, issuetGenesisState bool, issuerSecondState bool, fileName string, testProofType verifiable.ProofType, isBJJAuthEnabled int) {
+	generateDataWithEthKey(t, desc, gistData, userFirstState, userSecondState, issuetGenesisState, issuerSecondState, fileName, testProofType, isBJJAuthEnabled, utils.NewEthIdentity(t, EthPK))
+}
+
+// generateDataWithEthKey generates data of the proof submitted from the
+// address of ethUser, the challenge is the address. If auth is disabled the
+// user is the identity of ethUser, the vector has msgSender and the challenge
+// signed by ethUser. Otherwise the user is the BJJ identity of UserPK, which
+// signs the challenge with its auth key in the inputs.
+func generateDataWithEthKey(t *testing.T, desc string, gistData []*gistData, userFirstState bool, userSecondState bool, issuetGenesisState bool, issuerSecondState bool, fileName string, testProofType verifiable.ProofType, isBJJAuthEnabled int, ethUser *utils.EthIdentityTest) {
 
 	var linkNonce = "18"
 	var nullifierSessionID string = "1234569"
@@ -254,27 +280,29 @@ func generateData(t *testing.T, desc string, gistData []*gistData, userFirstStat
 	valueInput := utils.PrepareStrArray([]string{"20010101"}, 64)
 
 	var user *utils.IdentityTest
+	// verifier checks the challenge is msg.sender
+	challenge := ethUser.Challenge()
+	var msgSender, challengeSignature string
 
 	if isBJJAuthEnabled == 1 {
 		user = utils.NewIdentity(t, UserPK)
 	} else {
 		// generate onchain identity
-		user = utils.NewEthereumBasedIdentity(t, ethAddress)
+		user = ethUser.IdentityTest
+		msgSender = ethUser.MsgSender()
+		challengeSignature = hexutil.Encode(ethUser.SignChallenge(t, challenge))
 		nullifierSessionID = "0"
 	}
 	issuer := utils.NewIdentity(t, IssuerPK)
 
 	userProfileID := user.ID
-	nonce := big.NewInt(0)
 
 	subjectID := user.ID
-
-	var nonceSubject = new(big.Int)
 	if isSubjectIDProfile && isBJJAuthEnabled == 1 {
-		nonceSubject = big.NewInt(999)
-		subjectID, err = core.ProfileID(user.ID, nonceSubject)
-		require.NoError(t, err)
+		subjectID = user.AddProfile(t, utils.SubjectProfile, big.NewInt(utils.SubjectProfileNonce))
 	}
+	nonce := user.ProfileNonce(t, userProfileID)
+	nonceSubject := user.ProfileNonce(t, subjectID)
 
 	var claim *core.Claim
 	var mz *merklize.Merklizer
@@ -426,7 +454,6 @@ func generateData(t *testing.T, desc string, gistData []*gistData, userFirstStat
 	}
 
 	var authMTProof []string
-	var challenge *big.Int
 	var userAuthNonRevMTProof []string
 	var userNodeAuxNonRev utils.NodeAuxValue
 	var sig *babyjub.Signature
@@ -434,9 +461,6 @@ func generateData(t *testing.T, desc string, gistData []*gistData, userFirstStat
 	var gistProof []string
 	var gistNodeAux utils.NodeAuxValue
 
-	addr := common.HexToAddress(ethAddress)
-	challenge = new(big.Int).SetBytes(merkletree.SwapEndianness(addr.Bytes()))
-
 	// user
 	if isBJJAuthEnabled == 1 {
 		authMTProof = user.AuthMTPStrign(t)
@@ -478,6 +502,9 @@ func generateData(t *testing.T, desc string, gistData []*gistData, userFirstStat
 	t.Log(issuer.State(t).String())
 	valueArraySize := utils.GetValueArraySizeForOperator(operator)
 
+	verifierID, err := utils.VerifierID(utils.DefaultVerifierDID)
+	require.NoError(t, err)
+
 	inputs := Inputs{
 		RequestID:                       requestID,
 		UserGenesisID:                   user.ID.BigInt().String(),
@@ -548,23 +575,16 @@ func generateData(t *testing.T, desc string, gistData []*gistData, userFirstStat
 
 		ProofType: proofType,
 
-		VerifierID:         "21929109382993718606847853573861987353620810345503358891473103689157378049",
+		VerifierID:         verifierID.String(),
 		NullifierSessionID: nullifierSessionID,
 		IsBJJAuthEnabled:   isBJJAuthEnabled,
 	}
 
-	valuesHash, err := utils.PoseidonHashValue(utils.FromStringArrayToBigIntArray(inputs.Value))
-	require.NoError(t, err)
-	claimSchemaInt, ok := big.NewInt(0).SetString(inputs.ClaimSchema, 10)
-	require.True(t, ok)
-
 	linkID, err := utils.CalculateLinkID(linkNonce, claim)
 	require.NoError(t, err)
 
 	operatorOutput := "0"
 	nullifier := "0"
-	verifierID, ok := big.NewInt(0).SetString(inputs.VerifierID, 10)
-	require.True(t, ok)
 	nullifierSessionID_, ok := big.NewInt(0).SetString(inputs.NullifierSessionID, 10)
 	require.True(t, ok)
 	if inputs.NullifierSessionID != "0" {
@@ -580,27 +600,7 @@ func generateData(t *testing.T, desc string, gistData []*gistData, userFirstStat
 		)
 		require.NoError(t, err)
 	}
-	merklizedBigInt, ok := big.NewInt(0).SetString(merklized, 10)
-
-	firstPartQueryHash, err := poseidon.Hash([]*big.Int{
-		claimSchemaInt,
-		big.NewInt(int64(inputs.SlotIndex)),
-		big.NewInt(int64(inputs.Operator)),
-		pathKey,
-		merklizedBigInt,
-		valuesHash,
-	})
-	require.NoError(t, err)
-	require.True(t, ok)
-	circuitQueryHash, err := poseidon.Hash([]*big.Int{
-		firstPartQueryHash,
-		big.NewInt(int64(valueArraySize)),
-		big.NewInt(int64(isRevocationChecked)),
-		verifierID,
-		nullifierSessionID_,
-		new(big.Int),
-	})
-	require.NoError(t, err)
+	circuitQueryHash := calculateCircuitQueryHash(t, inputs, merklized, pathKey)
 
 	if operator == utils.SD {
 		operatorOutput = big.NewInt(10).String()
@@ -620,7 +620,7 @@ func generateData(t *testing.T, desc string, gistData []*gistData, userFirstStat
 		UserID:                 userProfileID.BigInt().String(),
 		IssuerID:               issuer.ID.BigInt().String(),
 		IssuerClaimNonRevState: issuer.State(t).String(),
-		CircuitQueryHash:       circuitQueryHash.String(),
+		CircuitQueryHash:       circuitQueryHash,
 		Timestamp:              timestamp,
 		Merklized:              merklized,
 		Challenge:              challenge.String(),
@@ -634,13 +634,17 @@ func generateData(t *testing.T, desc string, gistData []*gistData, userFirstStat
 	}
 
 	jsonData, err := json.Marshal(TestData{
-		desc,
-		inputs,
-		out,
+		Desc:               desc,
+		In:                 inputs,
+		Out:                out,
+		MsgSender:          msgSender,
+		ChallengeSignature: challengeSignature,
 	})
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(jsonData))
+	utils.SaveSnarkjsFiles(t, "credentialAtomicQueryV3OnChain", fileName, string(jsonData))
+	utils.SaveContractFixture(t, "credentialAtomicQueryV3OnChain", "v3/data", fileName, string(jsonData))
 }
 
 type Inputs struct {
@@ -756,4 +760,38 @@ type TestData struct {
 	Desc string  `json:"desc"`
 	In   Inputs  `json:"inputs"`
 	Out  Outputs `json:"expOut"`
+	// MsgSender is the address the proof must be submitted from, set for
+	// users authenticated by Ethereum address
+	MsgSender string `json:"msgSender,omitempty"`
+	// ChallengeSignature is the challenge signed by the key of MsgSender,
+	// hex encoded [R || S || V] for ecrecover
+	ChallengeSignature string `json:"challengeSignature,omitempty"`
+}
+
+func calculateCircuitQueryHash(t *testing.T, inputs Inputs, merklized string, pathKey *big.Int) string {
+	merklizedInt, err := strconv.Atoi(merklized)
+	require.NoError(t, err)
+
+	verifierID, ok := big.NewInt(0).SetString(inputs.VerifierID, 10)
+	require.True(t, ok)
+
+	nullifierSessionID_, ok := big.NewInt(0).SetString(inputs.NullifierSessionID, 10)
+	require.True(t, ok)
+
+	q := query.Query{
+		Inputs: query.Inputs{
+			ClaimSchema:         inputs.ClaimSchema,
+			ClaimPathKey:        pathKey.String(),
+			Operator:            inputs.Operator,
+			SlotIndex:           inputs.SlotIndex,
+			Value:               inputs.Value,
+			ValueArraySize:      inputs.ValueArraySize,
+			IsRevocationChecked: inputs.IsRevocationChecked,
+		},
+		Merklized: merklizedInt,
+	}
+	circuitQueryHash, err := q.CircuitQueryHash(verifierID, nullifierSessionID_)
+	require.NoError(t, err)
+
+	return circuitQueryHash.String()
 }
@@ -7,12 +7,12 @@ import (
 	"strconv"
 	"testing"
 
+	"test/query"
 	"test/utils"
 
 	"github.com/ethereum/go-ethereum/common"
 	core "github.com/iden3/go-iden3-core/v2"
 	"github.com/iden3/go-iden3-crypto/babyjub"
-	"github.com/iden3/go-iden3-crypto/poseidon"
 	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/iden3/go-merkletree-sql/v2/db/memory"
 	"github.com/iden3/go-schema-processor/v2/merklize"
@@ -287,6 +287,65 @@ func Test_Noop(t *testing.T) {
 	generateTestDataWithOperator(t, desc, isUserIDProfile, isSubjectIDProfile, "0", "sig/noop_operator", utils.NOOP, &value, Sig, 1)
 }
 
+func Test_SubjectPosition(t *testing.T) {
+	other := utils.NewIdentity(t, issuerPK).ID
+	cases := []struct {
+		Desc     string
+		FileName string
+		IsJSONLD bool
+		Options  []utils.ClaimOption
+	}{
+		{
+			"User == Subject. Claim non merklized claim. Subject in value",
+			"claim_subject_in_value", false,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionValue)},
+		},
+		// user doesn't own the claims below, ownership check of the circuit
+		// fails
+		{
+			"Self claim without subject. Ownership check fails",
+			"self_claim", false,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionNone)},
+		},
+		{
+			"Claim issued on other identity. Ownership check fails",
+			"claim_issued_on_other_identity", false,
+			[]utils.ClaimOption{utils.WithSubject(other)},
+		},
+		{
+			"User == Subject. Merklized claim. Subject in value",
+			"claim_subject_in_value_merklized", true,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionValue)},
+		},
+	}
+	for _, c := range cases {
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generateTestDataWithOperatorAndRevCheck(t, c.Desc, false, false, "0", "0",
+				string(proofType)+"/"+c.FileName, utils.EQ, nil, false, 1, c.IsJSONLD, proofType, 1, c.Options...)
+		}
+	}
+}
+
+func Test_ClaimSlots(t *testing.T) {
+	slots := []struct {
+		Slot int
+		Name string
+	}{
+		{utils.SlotIndexB, "index_b"},
+		{utils.SlotValueA, "value_a"},
+		{utils.SlotValueB, "value_b"},
+	}
+	// slot A of default claim is 10, other value is queried
+	value := []string{"20"}
+	for _, s := range slots {
+		desc := "User == Subject. Claim non merklized claim. Queried value in slot " + strconv.Itoa(s.Slot)
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generateTestDataWithSlot(t, desc, false, false, "0", "0", string(proofType)+"/claim_slot_"+s.Name,
+				utils.EQ, &value, false, 1, false, proofType, 1, s.Slot, utils.WithSlot(s.Slot, 20))
+		}
+	}
+}
+
 func generateTestData(t *testing.T, desc string, isUserIDProfile, isSubjectIDProfile bool,
 	linkNonce string, fileName string, proofType ProofType) {
 	generateTestDataWithOperatorAndRevCheck(t, desc, isUserIDProfile, isSubjectIDProfile, linkNonce, "0", fileName, utils.EQ, nil, false, 1, false, proofType, 1)
@@ -308,7 +367,16 @@ func generateJSONLDTestData(t *testing.T, desc string, isUserIDProfile, isSubjec
 
 func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserIDProfile, isSubjectIDProfile bool,
 	linkNonce, nullifierSessionID, fileName string, operator int, value *[]string, isRevoked bool, isRevocationChecked int, isJSONLD bool, testProofType ProofType,
-	isBJJAuthEnabled int) {
+	isBJJAuthEnabled int, claimOpts ...utils.ClaimOption) {
+	generateTestDataWithSlot(t, desc, isUserIDProfile, isSubjectIDProfile, linkNonce, nullifierSessionID, fileName, operator, value,
+		isRevoked, isRevocationChecked, isJSONLD, testProofType, isBJJAuthEnabled, utils.SlotIndexA, claimOpts...)
+}
+
+// generateTestDataWithSlot queries slotIndex of non-merklized claim, the slot
+// is set by claimOpts
+func generateTestDataWithSlot(t *testing.T, desc string, isUserIDProfile, isSubjectIDProfile bool,
+	linkNonce, nullifierSessionID, fileName string, operator int, value *[]string, isRevoked bool, isRevocationChecked int, isJSONLD bool, testProofType ProofType,
+	isBJJAuthEnabled int, slotIndex int, claimOpts ...utils.ClaimOption) {
 	var err error
 
 	valueInput := []string{"10"}
@@ -331,20 +399,16 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 	issuer := utils.NewIdentity(t, issuerPK)
 
 	userProfileID := user.ID
-	nonce := big.NewInt(0)
 	if isUserIDProfile {
-		nonce = big.NewInt(10)
-		userProfileID, err = core.ProfileID(user.ID, nonce)
-		require.NoError(t, err)
+		userProfileID = user.AddProfile(t, utils.UserProfile, big.NewInt(utils.UserProfileNonce))
 	}
 
 	subjectID := user.ID
-	nonceSubject := big.NewInt(0)
 	if isSubjectIDProfile {
-		nonceSubject = big.NewInt(999)
-		subjectID, err = core.ProfileID(user.ID, nonceSubject)
-		require.NoError(t, err)
+		subjectID = user.AddProfile(t, utils.SubjectProfile, big.NewInt(utils.SubjectProfileNonce))
 	}
+	nonce := user.ProfileNonce(t, userProfileID)
+	nonceSubject := user.ProfileNonce(t, subjectID)
 
 	var claim *core.Claim
 	var mz *merklize.Merklizer
@@ -353,7 +417,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 	var pathKey *big.Int
 
 	if isJSONLD {
-		mz, claim = utils.DefaultJSONUserClaim(t, subjectID)
+		mz, claim = utils.DefaultJSONUserClaim(t, subjectID, claimOpts...)
 		path, err := merklize.NewPath(
 			"https://www.w3.org/2018/credentials#credentialSubject",
 			"https://w3id.org/citizenship#residentSince")
@@ -377,7 +441,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		merklized = "1"
 
 	} else {
-		claim = utils.DefaultUserClaim(t, subjectID, nil)
+		claim = utils.DefaultUserClaim(t, subjectID, nil, claimOpts...)
 		claimPathMtp = utils.PrepareStrArray([]string{}, 32)
 		claimPathMtpNoAux = "0"
 		claimPathMtpAuxHi = "0"
@@ -401,7 +465,6 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		issuerClaimIdenState, proofType, issuerAuthClaimsTreeRoot,
 		issuerAuthRevTreeRoot, issuerAuthRootsTreeRoot, issuerAuthState string
 	var issuerAuthClaim *core.Claim
-	var slotIndex int
 	if testProofType == Sig {
 		// Sig claim
 		claimSig := issuer.SignClaim(t, claim)
@@ -431,8 +494,6 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 		issuerAuthState = issuer.State(t).String()
 
-		slotIndex = 2
-
 		proofType = "1"
 	} else {
 		issuer.AddClaim(t, claim)
@@ -461,7 +522,6 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 		issuerAuthState = "0"
 
-		slotIndex = 2
 		proofType = "2"
 	}
 
@@ -522,6 +582,9 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		}
 	}
 
+	verifierID, err := utils.VerifierID(utils.DefaultVerifierDID)
+	require.NoError(t, err)
+
 	inputs := Inputs{
 		RequestID:                       requestID.String(),
 		UserGenesisID:                   user.ID.BigInt().String(),
@@ -593,23 +656,16 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 		ProofType: proofType,
 
-		VerifierID:         "21929109382993718606847853573861987353620810345503358891473103689157378049",
+		VerifierID:         verifierID.String(),
 		NullifierSessionID: nullifierSessionID,
 		IsBJJAuthEnabled:   isBJJAuthEnabled,
 	}
 
-	valuesHash, err := utils.PoseidonHashValue(utils.FromStringArrayToBigIntArray(inputs.Value))
-	require.NoError(t, err)
-	claimSchemaInt, ok := big.NewInt(0).SetString(inputs.ClaimSchema, 10)
-	require.True(t, ok)
-
 	linkID, err := utils.CalculateLinkID(linkNonce, claim)
 	require.NoError(t, err)
 
 	operatorOutput := "0"
 	nullifier := "0"
-	verifierID, ok := big.NewInt(0).SetString(inputs.VerifierID, 10)
-	require.True(t, ok)
 	nullifierSessionID_, ok := big.NewInt(0).SetString(inputs.NullifierSessionID, 10)
 	require.True(t, ok)
 	if inputs.NullifierSessionID != "0" {
@@ -625,27 +681,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		)
 		require.NoError(t, err)
 	}
-	merklizedBigInt, ok := big.NewInt(0).SetString(merklized, 10)
-
-	firstPartQueryHash, err := poseidon.Hash([]*big.Int{
-		claimSchemaInt,
-		big.NewInt(int64(inputs.SlotIndex)),
-		big.NewInt(int64(inputs.Operator)),
-		pathKey,
-		merklizedBigInt,
-		valuesHash,
-	})
-	require.NoError(t, err)
-	require.True(t, ok)
-	circuitQueryHash, err := poseidon.Hash([]*big.Int{
-		firstPartQueryHash,
-		big.NewInt(int64(valueArraySize)),
-		big.NewInt(int64(isRevocationChecked)),
-		verifierID,
-		nullifierSessionID_,
-		new(big.Int),
-	})
-	require.NoError(t, err)
+	circuitQueryHash := calculateCircuitQueryHash(t, inputs, merklized, pathKey)
 
 	if operator == utils.SD {
 		operatorOutput = big.NewInt(10).String()
@@ -665,7 +701,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		UserID:                 userProfileID.BigInt().String(),
 		IssuerID:               issuer.ID.BigInt().String(),
 		IssuerClaimNonRevState: issuer.State(t).String(),
-		CircuitQueryHash:       circuitQueryHash.String(),
+		CircuitQueryHash:       circuitQueryHash,
 		Timestamp:              timestamp,
 		Challenge:              challenge.String(),
 		GistRoot:               gistRoot.BigInt().String(),
@@ -685,6 +721,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(jsonData))
+	utils.SaveSnarkjsFiles(t, "credentialAtomicQueryV3OnChain", fileName, string(jsonData))
 }
 
 func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubjectIDProfile bool, desc,
@@ -696,20 +733,16 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 	issuer := utils.NewIdentity(t, issuerPK)
 
 	userProfileID := user.ID
-	nonce := big.NewInt(0)
 	if isUserIDProfile {
-		nonce = big.NewInt(10)
-		userProfileID, err = core.ProfileID(user.ID, nonce)
-		require.NoError(t, err)
+		userProfileID = user.AddProfile(t, utils.UserProfile, big.NewInt(utils.UserProfileNonce))
 	}
 
 	subjectID := user.ID
-	nonceSubject := big.NewInt(0)
 	if isSubjectIDProfile {
-		nonceSubject = big.NewInt(999)
-		subjectID, err = core.ProfileID(user.ID, nonceSubject)
-		require.NoError(t, err)
+		subjectID = user.AddProfile(t, utils.SubjectProfile, big.NewInt(utils.SubjectProfileNonce))
 	}
+	nonce := user.ProfileNonce(t, userProfileID)
+	nonceSubject := user.ProfileNonce(t, subjectID)
 
 	mz, claim := utils.DefaultJSONUserClaim(t, subjectID)
 
@@ -756,6 +789,9 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 
 	valueArraySize := utils.GetValueArraySizeForOperator(utils.EXISTS)
 
+	verifierID, err := utils.VerifierID(utils.DefaultVerifierDID)
+	require.NoError(t, err)
+
 	inputs := Inputs{
 		RequestID:                       requestID.String(),
 		UserGenesisID:                   user.ID.BigInt().String(),
@@ -831,7 +867,7 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 
 		ProofType: "1",
 
-		VerifierID:         "21929109382993718606847853573861987353620810345503358891473103689157378049",
+		VerifierID:         verifierID.String(),
 		NullifierSessionID: "0",
 
 		IsBJJAuthEnabled: 1,
@@ -839,34 +875,7 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 
 	issuerAuthState := issuer.State(t)
 
-	valuesHash, err := utils.PoseidonHashValue(utils.FromStringArrayToBigIntArray(inputs.Value))
-	require.NoError(t, err)
-	claimSchemaInt, ok := big.NewInt(0).SetString(inputs.ClaimSchema, 10)
-	require.True(t, ok)
-
-	firstPartQueryHash, err := poseidon.Hash([]*big.Int{
-		claimSchemaInt,
-		big.NewInt(int64(inputs.SlotIndex)),
-		big.NewInt(int64(inputs.Operator)),
-		pathKey,
-		big.NewInt(1), // merklized
-		valuesHash,
-	})
-	require.NoError(t, err)
-	verifierID, ok := big.NewInt(0).SetString(inputs.VerifierID, 10)
-	require.True(t, ok)
-	nullifierSessionID_, ok := big.NewInt(0).SetString(inputs.NullifierSessionID, 10)
-	require.True(t, ok)
-
-	circuitQueryHash, err := poseidon.Hash([]*big.Int{
-		firstPartQueryHash,
-		big.NewInt(int64(valueArraySize)),
-		big.NewInt(1),
-		verifierID,
-		nullifierSessionID_,
-		big.NewInt(0),
-	})
-	require.NoError(t, err)
+	circuitQueryHash := calculateCircuitQueryHash(t, inputs, "1", pathKey)
 
 	out := Outputs{
 		RequestID:              requestID.String(),
@@ -874,7 +883,7 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 		IssuerID:               issuer.ID.BigInt().String(),
 		IssuerClaimNonRevState: issuerClaimNonRevState.String(),
 		Timestamp:              timestamp,
-		CircuitQueryHash:       circuitQueryHash.String(),
+		CircuitQueryHash:       circuitQueryHash,
 		Challenge:              challenge.String(),
 		GistRoot:               gistRoot.BigInt().String(),
 		IssuerState:            issuerAuthState.String(),
@@ -893,4 +902,33 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(jsonData))
+	utils.SaveSnarkjsFiles(t, "credentialAtomicQueryV3OnChain", fileName, string(jsonData))
+}
+
+func calculateCircuitQueryHash(t *testing.T, inputs Inputs, merklized string, pathKey *big.Int) string {
+	merklizedInt, err := strconv.Atoi(merklized)
+	require.NoError(t, err)
+
+	verifierID, ok := big.NewInt(0).SetString(inputs.VerifierID, 10)
+	require.True(t, ok)
+
+	nullifierSessionID_, ok := big.NewInt(0).SetString(inputs.NullifierSessionID, 10)
+	require.True(t, ok)
+
+	q := query.Query{
+		Inputs: query.Inputs{
+			ClaimSchema:         inputs.ClaimSchema,
+			ClaimPathKey:        pathKey.String(),
+			Operator:            inputs.Operator,
+			SlotIndex:           inputs.SlotIndex,
+			Value:               inputs.Value,
+			ValueArraySize:      inputs.ValueArraySize,
+			IsRevocationChecked: inputs.IsRevocationChecked,
+		},
+		Merklized: merklizedInt,
+	}
+	circuitQueryHash, err := q.CircuitQueryHash(verifierID, nullifierSessionID_)
+	require.NoError(t, err)
+
+	return circuitQueryHash.String()
 }
@@ -4,12 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"math/big"
+	"strconv"
 	"testing"
 
+	"test/query"
 	"test/utils"
 
 	core "github.com/iden3/go-iden3-core/v2"
-	"github.com/iden3/go-iden3-crypto/poseidon"
 	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/iden3/go-schema-processor/v2/merklize"
 	"github.com/stretchr/testify/require"
@@ -273,6 +274,45 @@ func Test_Less_Than_Eq(t *testing.T) {
 	generateTestDataWithOperator(t, desc, isUserIDProfile, isSubjectIDProfile, "0", "sig/less_than_eq_operator", utils.LTE, &value, Sig)
 }
 
+func Test_SubjectPosition(t *testing.T) {
+	other := utils.NewIdentity(t, issuerPK).ID
+	cases := []struct {
+		Desc     string
+		FileName string
+		IsJSONLD bool
+		Options  []utils.ClaimOption
+	}{
+		{
+			"User == Subject. Claim non merklized claim. Subject in value",
+			"claim_subject_in_value", false,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionValue)},
+		},
+		// user doesn't own the claims below, ownership check of the circuit
+		// fails
+		{
+			"Self claim without subject. Ownership check fails",
+			"self_claim", false,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionNone)},
+		},
+		{
+			"Claim issued on other identity. Ownership check fails",
+			"claim_issued_on_other_identity", false,
+			[]utils.ClaimOption{utils.WithSubject(other)},
+		},
+		{
+			"User == Subject. Merklized claim. Subject in value",
+			"claim_subject_in_value_merklized", true,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionValue)},
+		},
+	}
+	for _, c := range cases {
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generateTestDataWithOperatorAndRevCheck(t, c.Desc, false, false, "0", "0",
+				string(proofType)+"/"+c.FileName, utils.EQ, nil, false, 1, c.IsJSONLD, false, proofType, c.Options...)
+		}
+	}
+}
+
 func generateTestData(t *testing.T, desc string, isUserIDProfile, isSubjectIDProfile bool,
 	linkNonce string, fileName string, proofType ProofType) {
 	generateTestDataWithOperatorAndRevCheck(t, desc, isUserIDProfile, isSubjectIDProfile, linkNonce, "0", fileName, utils.EQ, nil, false, 1, false, false, proofType)
@@ -293,7 +333,8 @@ func generateJSONLDTestData(t *testing.T, desc string, isUserIDProfile, isSubjec
 }
 
 func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserIDProfile, isSubjectIDProfile bool,
-	linkNonce, nullifierSessionID, fileName string, operator int, value *[]string, isRevoked bool, isRevocationChecked int, isJSONLD bool, isZeroSubjClaim bool, testProofType ProofType) {
+	linkNonce, nullifierSessionID, fileName string, operator int, value *[]string, isRevoked bool, isRevocationChecked int, isJSONLD bool, isZeroSubjClaim bool, testProofType ProofType,
+	claimOpts ...utils.ClaimOption) {
 	var err error
 
 	valueInput := []string{"10"}
@@ -309,20 +350,16 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 	issuer := utils.NewIdentity(t, issuerPK)
 
 	userProfileID := user.ID
-	nonce := big.NewInt(0)
 	if isUserIDProfile {
-		nonce = big.NewInt(10)
-		userProfileID, err = core.ProfileID(user.ID, nonce)
-		require.NoError(t, err)
+		userProfileID = user.AddProfile(t, utils.UserProfile, big.NewInt(utils.UserProfileNonce))
 	}
 
 	subjectID := user.ID
-	nonceSubject := big.NewInt(0)
 	if isSubjectIDProfile {
-		nonceSubject = big.NewInt(999)
-		subjectID, err = core.ProfileID(user.ID, nonceSubject)
-		require.NoError(t, err)
+		subjectID = user.AddProfile(t, utils.SubjectProfile, big.NewInt(utils.SubjectProfileNonce))
 	}
+	nonce := user.ProfileNonce(t, userProfileID)
+	nonceSubject := user.ProfileNonce(t, subjectID)
 
 	var claim *core.Claim
 	var mz *merklize.Merklizer
@@ -331,7 +368,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 	var pathKey *big.Int
 
 	if isJSONLD {
-		mz, claim = utils.DefaultJSONUserClaim(t, subjectID)
+		mz, claim = utils.DefaultJSONUserClaim(t, subjectID, claimOpts...)
 		path, err := merklize.NewPath(
 			"https://www.w3.org/2018/credentials#credentialSubject",
 			"https://w3id.org/citizenship#residentSince")
@@ -359,7 +396,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		if isZeroSubjClaim {
 			subjValue = big.NewInt(0)
 		}
-		claim = utils.DefaultUserClaim(t, subjectID, subjValue)
+		claim = utils.DefaultUserClaim(t, subjectID, subjValue, claimOpts...)
 		claimPathMtp = utils.PrepareStrArray([]string{}, 32)
 		claimPathMtpNoAux = "0"
 		claimPathMtpAuxHi = "0"
@@ -451,6 +488,9 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 	requestID := big.NewInt(23)
 
+	verifierID, err := utils.VerifierID(utils.DefaultVerifierDID)
+	require.NoError(t, err)
+
 	inputs := Inputs{
 		RequestID:                       requestID.String(),
 		UserGenesisID:                   user.ID.BigInt().String(),
@@ -503,7 +543,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 		ProofType: proofType,
 
-		VerifierID:         "21929109382993718606847853573861987353620810345503358891473103689157378049",
+		VerifierID:         verifierID.String(),
 		NullifierSessionID: nullifierSessionID,
 	}
 
@@ -516,9 +556,6 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 	require.True(t, ok)
 
 	if inputs.NullifierSessionID != "0" {
-		verifierID, ok := big.NewInt(0).SetString(inputs.VerifierID, 10)
-		require.True(t, ok)
-
 		nullifierSessionID_, ok := big.NewInt(0).SetString(inputs.NullifierSessionID, 10)
 		require.True(t, ok)
 
@@ -545,8 +582,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		issuerState = issuerClaimIdenState
 	}
 
-	circuitQueryHash, err := calculateCircuitQueryHash(t, inputs, merklized, pathKey)
-	require.NoError(t, err)
+	circuitQueryHash := calculateCircuitQueryHash(t, inputs, merklized, pathKey)
 
 	out := Outputs{
 		RequestID:              requestID.String(),
@@ -570,6 +606,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(jsonData))
+	utils.SaveSnarkjsFiles(t, "credentialAtomicQueryV3Universal", fileName, string(jsonData))
 }
 
 func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubjectIDProfile bool, desc,
@@ -581,20 +618,16 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 	issuer := utils.NewIdentity(t, issuerPK)
 
 	userProfileID := user.ID
-	nonce := big.NewInt(0)
 	if isUserIDProfile {
-		nonce = big.NewInt(10)
-		userProfileID, err = core.ProfileID(user.ID, nonce)
-		require.NoError(t, err)
+		userProfileID = user.AddProfile(t, utils.UserProfile, big.NewInt(utils.UserProfileNonce))
 	}
 
 	subjectID := user.ID
-	nonceSubject := big.NewInt(0)
 	if isSubjectIDProfile {
-		nonceSubject = big.NewInt(999)
-		subjectID, err = core.ProfileID(user.ID, nonceSubject)
-		require.NoError(t, err)
+		subjectID = user.AddProfile(t, utils.SubjectProfile, big.NewInt(utils.SubjectProfileNonce))
 	}
+	nonce := user.ProfileNonce(t, userProfileID)
+	nonceSubject := user.ProfileNonce(t, subjectID)
 
 	mz, claim := utils.DefaultJSONUserClaim(t, subjectID)
 
@@ -622,6 +655,9 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 
 	requestID := big.NewInt(23)
 
+	verifierID, err := utils.VerifierID(utils.DefaultVerifierDID)
+	require.NoError(t, err)
+
 	inputs := Inputs{
 		RequestID:                       requestID.String(),
 		UserGenesisID:                   user.ID.BigInt().String(),
@@ -678,12 +714,11 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 
 		ProofType: "1",
 
-		VerifierID:         "21929109382993718606847853573861987353620810345503358891473103689157378049",
+		VerifierID:         verifierID.String(),
 		NullifierSessionID: "0",
 	}
 
-	circuitQueryHash, err := calculateCircuitQueryHash(t, inputs, "1", pathKey)
-	require.NoError(t, err)
+	circuitQueryHash := calculateCircuitQueryHash(t, inputs, "1", pathKey)
 
 	issuerAuthState := issuer.State(t)
 
@@ -709,28 +744,12 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(jsonData))
+	utils.SaveSnarkjsFiles(t, "credentialAtomicQueryV3Universal", fileName, string(jsonData))
 }
 
-func calculateCircuitQueryHash(t *testing.T, inputs Inputs, merklized string, pathKey *big.Int) (string, error) {
-	merklizedBigInt, ok := big.NewInt(0).SetString(merklized, 10)
-	require.True(t, ok)
-
-	valuesHash, err := utils.PoseidonHashValue(utils.FromStringArrayToBigIntArray(inputs.Value))
-	require.NoError(t, err)
-
-	claimSchemaInt, ok := big.NewInt(0).SetString(inputs.ClaimSchema, 10)
-	require.True(t, ok)
-
-	firstPartQueryHash, err := poseidon.Hash([]*big.Int{
-		claimSchemaInt,
-		big.NewInt(int64(inputs.SlotIndex)),
-		big.NewInt(int64(inputs.Operator)),
-		pathKey,
-		merklizedBigInt,
-		valuesHash,
-	})
+func calculateCircuitQueryHash(t *testing.T, inputs Inputs, merklized string, pathKey *big.Int) string {
+	merklizedInt, err := strconv.Atoi(merklized)
 	require.NoError(t, err)
-	require.True(t, ok)
 
 	verifierID, ok := big.NewInt(0).SetString(inputs.VerifierID, 10)
 	require.True(t, ok)
@@ -738,16 +757,20 @@ func calculateCircuitQueryHash(t *testing.T, inputs Inputs, merklized string, pa
 	nullifierSessionID_, ok := big.NewInt(0).SetString(inputs.NullifierSessionID, 10)
 	require.True(t, ok)
 
-	circuitQueryHash, err := poseidon.Hash([]*big.Int{
-		firstPartQueryHash,
-		big.NewInt(int64(inputs.ValueArraySize)),
-		big.NewInt(int64(inputs.IsRevocationChecked)),
-		verifierID,
-		nullifierSessionID_,
-		new(big.Int),
-	})
-
+	q := query.Query{
+		Inputs: query.Inputs{
+			ClaimSchema:         inputs.ClaimSchema,
+			ClaimPathKey:        pathKey.String(),
+			Operator:            inputs.Operator,
+			SlotIndex:           inputs.SlotIndex,
+			Value:               inputs.Value,
+			ValueArraySize:      inputs.ValueArraySize,
+			IsRevocationChecked: inputs.IsRevocationChecked,
+		},
+		Merklized: merklizedInt,
+	}
+	circuitQueryHash, err := q.CircuitQueryHash(verifierID, nullifierSessionID_)
 	require.NoError(t, err)
 
-	return circuitQueryHash.String(), nil
+	return circuitQueryHash.String()
 }
@@ -0,0 +1,78 @@
+package v3
+
+import (
+	"math/big"
+	"testing"
+
+	"test/linked"
+	"test/utils"
+
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_LinkedProofs generates credentialAtomicQueryV3 vectors and
+// linkedMultiQuery vectors of all sizes of the same claim and link nonce.
+// The verifier links the proofs by their linkID outputs, so they must be
+// equal.
+func Test_LinkedProofs(t *testing.T) {
+	const linkNonce = "18"
+
+	cases := []struct {
+		Name     string
+		Desc     string
+		IsJSONLD bool
+	}{
+		{"non_merklized", "Linked proofs. Claim non merklized claim", false},
+		{"merklized", "Linked proofs. Merklized claim", true},
+	}
+
+	for _, c := range cases {
+		var v3Data []TestData
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			v3Data = append(v3Data, generate(t, testCase{
+				Desc:                c.Desc,
+				FileName:            string(proofType) + "/linked_" + c.Name,
+				LinkNonce:           linkNonce,
+				NullifierSessionID:  "0",
+				Operator:            utils.EQ,
+				IsRevocationChecked: 1,
+				IsJSONLD:            c.IsJSONLD,
+				ProofType:           proofType,
+			}))
+		}
+		in := v3Data[0].In
+
+		claim := linked.Claim{Claim: in.IssuerClaim}
+		var path merklize.Path
+		if c.IsJSONLD {
+			user := utils.NewIdentity(t, userPK)
+			mz, mzClaim := utils.DefaultJSONUserClaim(t, user.ID)
+			require.Equal(t, in.IssuerClaim, mzClaim)
+			var err error
+			path, err = merklize.NewPath(
+				"https://www.w3.org/2018/credentials#credentialSubject",
+				"https://w3id.org/citizenship#residentSince")
+			require.NoError(t, err)
+			claim = linked.Claim{Claim: mzClaim, Merklizer: mz}
+		}
+
+		// the value queried by V3 proof and other queries of it
+		value, ok := big.NewInt(0).SetString(in.Value[0], 10)
+		require.True(t, ok)
+		next := new(big.Int).Add(value, big.NewInt(1))
+		queries := []linked.Query{
+			{Operator: utils.EQ, Values: []*big.Int{value}, Path: path, SlotIndex: in.SlotIndex},
+			{Operator: utils.NE, Values: []*big.Int{next}, Path: path, SlotIndex: in.SlotIndex},
+			{Operator: utils.LT, Values: []*big.Int{next}, Path: path, SlotIndex: in.SlotIndex},
+		}
+		for _, size := range linked.Sizes {
+			data := linked.Generate(t, c.Desc, size, claim, linkNonce, queries)
+			for _, d := range v3Data {
+				require.NotEqual(t, "0", d.Out.LinkID)
+				require.Equal(t, d.Out.LinkID, data.Out.LinkID)
+			}
+			linked.Save(t, linked.Dir(size)+"/"+c.Name, data)
+		}
+	}
+}
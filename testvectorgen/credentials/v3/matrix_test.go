@@ -0,0 +1,239 @@
+package v3
+
+import (
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"test/utils"
+	"test/vector"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// matrixCase is a combination of query parameters of the matrix mode
+type matrixCase struct {
+	UserProfile       bool
+	SubjectProfile    bool
+	SubjectInValue    bool
+	ProofType         ProofType
+	Merklized         bool
+	Operator          int
+	Revoked           bool
+	RevocationChecked bool
+	LinkNonce         bool
+	Nullifier         bool
+}
+
+var matrixOperators = []int{
+	utils.NOOP, utils.EQ, utils.LT, utils.GT, utils.IN, utils.NIN, utils.NE,
+	utils.LTE, utils.GTE, utils.BETWEEN, utils.NOT_BETWEEN, utils.EXISTS, utils.SD,
+}
+
+// Test_Matrix generates vectors for the cartesian product of the query
+// parameters. It writes thousands of files, so it runs only if V3_MATRIX
+// environment variable is set.
+func Test_Matrix(t *testing.T) {
+	if os.Getenv("V3_MATRIX") == "" {
+		t.Skip("set V3_MATRIX=1 to generate matrix vectors")
+	}
+
+	for _, c := range matrix() {
+		c, tc := c, c.testCase()
+		t.Run(tc.FileName, func(t *testing.T) {
+			out := generate(t, tc).Out
+			// nullifier is 0 for claims issued on genesis id, the circuit
+			// outputs it even if nullifier session is requested
+			if c.Nullifier && c.SubjectProfile {
+				require.NotEqual(t, "0", out.Nullifier)
+			} else {
+				require.Equal(t, "0", out.Nullifier)
+			}
+		})
+	}
+}
+
+func Test_MatrixCases(t *testing.T) {
+	cases := matrix()
+
+	fileNames := map[string]bool{}
+	for _, c := range cases {
+		require.Empty(t, c.skipReason())
+		fileName := c.fileName()
+		require.False(t, fileNames[fileName], fileName)
+		fileNames[fileName] = true
+	}
+
+	// 2 user * 2 subject * 2 subject positions * 2 proof types * 2 link
+	// nonce * 2 nullifier * 3 valid of 4 revocation states * (13 operators
+	// merklized + 12 slot)
+	require.Len(t, cases, 2*2*2*2*2*2*3*25)
+	require.True(t, fileNames["matrix/mtp/user_profile-subject-slot-eq-not_revoked_checked-no_link-nullifier"])
+
+	tc := matrixCase{
+		UserProfile: true, SubjectProfile: true, SubjectInValue: true, ProofType: Sig,
+		Merklized: true, Operator: utils.NOT_BETWEEN, RevocationChecked: true,
+		LinkNonce: true, Nullifier: true,
+	}.testCase()
+	require.Equal(t,
+		"matrix/sig/user_profile-subject_profile_in_value-merklized-not_between-not_revoked_checked-link-nullifier",
+		tc.FileName)
+	require.Equal(t, []string{"11", "12"}, tc.QueryValue(big.NewInt(10)))
+}
+
+// matrix returns all valid combinations of the query parameters
+func matrix() []matrixCase {
+	var cases []matrixCase
+	bools := []bool{false, true}
+	for _, userProfile := range bools {
+		for _, subjectProfile := range bools {
+			for _, subjectInValue := range bools {
+				for _, proofType := range []ProofType{Sig, Mtp} {
+					for _, merklized := range bools {
+						for _, operator := range matrixOperators {
+							for _, revoked := range bools {
+								for _, revocationChecked := range bools {
+									for _, linkNonce := range bools {
+										for _, nullifier := range bools {
+											c := matrixCase{
+												UserProfile:       userProfile,
+												SubjectProfile:    subjectProfile,
+												SubjectInValue:    subjectInValue,
+												ProofType:         proofType,
+												Merklized:         merklized,
+												Operator:          operator,
+												Revoked:           revoked,
+												RevocationChecked: revocationChecked,
+												LinkNonce:         linkNonce,
+												Nullifier:         nullifier,
+											}
+											if c.skipReason() == "" {
+												cases = append(cases, c)
+											}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return cases
+}
+
+// skipReason returns why the combination is not generated, empty for valid
+// combinations
+func (c matrixCase) skipReason() string {
+	switch {
+	case c.Revoked && c.RevocationChecked:
+		return "circuit fails on revoked claim with revocation check"
+	case c.Operator == utils.EXISTS && !c.Merklized:
+		return "exists operator is not supported for non-merklized claims"
+	default:
+		return ""
+	}
+}
+
+func (c matrixCase) fileName() string {
+	parts := []string{"user", "subject", "slot"}
+	if c.UserProfile {
+		parts[0] = "user_profile"
+	}
+	if c.SubjectProfile {
+		parts[1] = "subject_profile"
+	}
+	if c.SubjectInValue {
+		parts[1] += "_in_value"
+	}
+	if c.Merklized {
+		parts[2] = "merklized"
+	}
+
+	parts = append(parts, strings.ToLower(vector.OperatorName(c.Operator)))
+
+	switch {
+	case c.Revoked:
+		parts = append(parts, "revoked_unchecked")
+	case c.RevocationChecked:
+		parts = append(parts, "not_revoked_checked")
+	default:
+		parts = append(parts, "not_revoked_unchecked")
+	}
+
+	if c.LinkNonce {
+		parts = append(parts, "link")
+	} else {
+		parts = append(parts, "no_link")
+	}
+	if c.Nullifier {
+		parts = append(parts, "nullifier")
+	} else {
+		parts = append(parts, "no_nullifier")
+	}
+
+	return path.Join("matrix", string(c.ProofType), strings.Join(parts, "-"))
+}
+
+func (c matrixCase) testCase() testCase {
+	tc := testCase{
+		Desc:                "Matrix: " + strings.ReplaceAll(path.Base(c.fileName()), "-", ", "),
+		FileName:            c.fileName(),
+		IsUserIDProfile:     c.UserProfile,
+		IsSubjectIDProfile:  c.SubjectProfile,
+		LinkNonce:           "0",
+		NullifierSessionID:  "0",
+		Operator:            c.Operator,
+		QueryValue:          matrixQueryValue(c.Operator),
+		IsRevoked:           c.Revoked,
+		IsRevocationChecked: 0,
+		IsJSONLD:            c.Merklized,
+		ProofType:           c.ProofType,
+	}
+	if c.RevocationChecked {
+		tc.IsRevocationChecked = 1
+	}
+	if c.SubjectInValue {
+		tc.ClaimOptions = []utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionValue)}
+	}
+	if c.LinkNonce {
+		tc.LinkNonce = "6321"
+	}
+	if c.Nullifier {
+		tc.NullifierSessionID = "123"
+	}
+	return tc
+}
+
+// matrixQueryValue returns query value satisfied by the actual value of the
+// field for the operator
+func matrixQueryValue(operator int) func(fieldValue *big.Int) []string {
+	return func(v *big.Int) []string {
+		add := func(d int64) string {
+			return new(big.Int).Add(v, big.NewInt(d)).String()
+		}
+		switch operator {
+		case utils.EQ, utils.LTE, utils.GTE:
+			return []string{add(0)}
+		case utils.LT, utils.NE:
+			return []string{add(1)}
+		case utils.GT:
+			return []string{add(-1)}
+		case utils.IN:
+			return []string{add(-1), add(0), add(1)}
+		case utils.NIN, utils.NOT_BETWEEN:
+			return []string{add(1), add(2)}
+		case utils.BETWEEN:
+			return []string{add(-1), add(1)}
+		case utils.EXISTS:
+			return []string{"1"}
+		default:
+			// NOOP and selective disclosure take no values
+			return []string{}
+		}
+	}
+}
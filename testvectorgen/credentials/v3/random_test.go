@@ -0,0 +1,105 @@
+package v3
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"test/scenario"
+	"test/utils"
+
+	core "github.com/iden3/go-iden3-core/v2"
+)
+
+// Test_RandomScenarios generates vectors for random identities, claims and
+// queries. It runs only if SCENARIO_SEED environment variable is set, the same
+// seed reproduces the same vectors. SCENARIO_COUNT sets number of vectors.
+func Test_RandomScenarios(t *testing.T) {
+	if os.Getenv(scenario.SeedEnv) == "" {
+		t.Skipf("set %s to generate random vectors", scenario.SeedEnv)
+	}
+	count := 10
+	if s := os.Getenv("SCENARIO_COUNT"); s != "" {
+		var err error
+		count, err = strconv.Atoi(s)
+		if err != nil {
+			t.Fatalf("invalid SCENARIO_COUNT: %v", err)
+		}
+	}
+
+	r := scenario.FromEnv(t)
+	for i := 0; i < count; i++ {
+		tc := randomTestCase(t, r, i)
+		t.Run(tc.FileName, func(t *testing.T) {
+			generate(t, tc)
+		})
+	}
+}
+
+func randomTestCase(t *testing.T, r *scenario.Rand, i int) testCase {
+	timestamp := r.Timestamp()
+	profileNonce := r.ProfileNonce()
+	subjectProfileNonce := r.ProfileNonce()
+
+	proofType := Sig
+	if r.Bool() {
+		proofType = Mtp
+	}
+
+	linkNonce := "0"
+	if r.Bool() {
+		linkNonce = r.Nonce().String()
+	}
+	nullifierSessionID := "0"
+	if subjectProfileNonce.Sign() != 0 && r.Bool() {
+		nullifierSessionID = r.Nonce().String()
+	}
+
+	isRevocationChecked := r.Intn(2)
+
+	user := r.Identity(t, 300)
+	issuer := r.Identity(t, 300)
+	subjectID := user.ID
+	if subjectProfileNonce.Sign() != 0 {
+		subjectID = user.AddProfile(t, utils.SubjectProfile, subjectProfileNonce)
+	}
+	// claim is created here to derive the query from its value
+	claim := r.Claim(t, subjectID, timestamp)
+	operator, values := r.Query(claim.Value)
+
+	return testCase{
+		Desc: fmt.Sprintf("Random scenario: seed %d, case %d, %s, operator %d",
+			r.Seed, i, proofType, operator),
+		FileName:            fmt.Sprintf("random/%d/%d", r.Seed, i),
+		IsUserIDProfile:     profileNonce.Sign() != 0,
+		IsSubjectIDProfile:  subjectProfileNonce.Sign() != 0,
+		LinkNonce:           linkNonce,
+		NullifierSessionID:  nullifierSessionID,
+		Operator:            operator,
+		QueryValue:          func(*big.Int) []string { return bigIntStrings(values) },
+		IsRevocationChecked: isRevocationChecked,
+		ProofType:           proofType,
+		User:                user,
+		Issuer:              issuer,
+		ProfileNonce:        profileNonce,
+		SubjectProfileNonce: subjectProfileNonce,
+		Claim: func(subject core.ID) (*core.Claim, int) {
+			if subject != subjectID {
+				t.Fatalf("unexpected claim subject %s", subject)
+			}
+			return claim.Claim, claim.SlotIndex
+		},
+		Clock: utils.FixedClock(time.Unix(timestamp, 0)),
+	}
+}
+
+func bigIntStrings(values []*big.Int) []string {
+	res := make([]string, len(values))
+	for i, v := range values {
+		res[i] = v.String()
+	}
+	return res
+}
@@ -6,7 +6,9 @@ import (
 	"math/big"
 	"strconv"
 	"testing"
+	"time"
 
+	"test/onchain"
 	"test/utils"
 
 	core "github.com/iden3/go-iden3-core/v2"
@@ -16,9 +18,9 @@ import (
 )
 
 const (
-	userPK    = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69e"
-	issuerPK  = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69d"
-	timestamp = "1642074362"
+	userPK     = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69e"
+	issuerPK   = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69d"
+	ethAddress = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
 )
 
 type ProofType string
@@ -282,6 +284,360 @@ func Test_Less_Than_Eq(t *testing.T) {
 	generateTestDataWithOperator(t, desc, isUserIDProfile, isSubjectIDProfile, "0", "sig/less_than_eq_operator", utils.LTE, &value, Sig)
 }
 
+func Test_DeepProofs(t *testing.T) {
+	desc := "Issuer trees with fillers. Claim and non-revocation proofs of max depth"
+	for _, proofType := range []ProofType{Mtp, Sig} {
+		depth := utils.IdentityTreeLevels - 1
+		data := generate(t, testCase{
+			Desc:                desc,
+			FileName:            string(proofType) + "/deep_proofs",
+			LinkNonce:           "0",
+			NullifierSessionID:  "0",
+			Operator:            utils.EQ,
+			IsRevocationChecked: 1,
+			ProofType:           proofType,
+			Issuer: utils.NewIdentity(t, issuerPK, utils.WithFillers(100),
+				utils.WithProofDepth(depth)),
+			ProofDepth: depth,
+		})
+		// auth claim of the filled issuer has non-empty claims tree proof, it
+		// differs from the non-revocation one
+		if proofType == Sig {
+			require.True(t, verifyAuthClaimMtp(t, data.In),
+				"issuerAuthClaimMtp doesn't prove auth claim in issuer claims tree")
+		}
+	}
+}
+
+// verifyAuthClaimMtp checks issuerAuthClaimMtp of the inputs against
+// issuerAuthClaimsTreeRoot
+func verifyAuthClaimMtp(t *testing.T, in Inputs) bool {
+	t.Helper()
+	siblings := make([]*merkletree.Hash, 0, len(in.IssuerAuthClaimMtp))
+	for _, s := range in.IssuerAuthClaimMtp {
+		h, err := merkletree.NewHashFromString(s)
+		require.NoError(t, err)
+		siblings = append(siblings, h)
+	}
+	// siblings are padded with zeros to the tree levels
+	for len(siblings) > 0 && siblings[len(siblings)-1].Equals(&merkletree.HashZero) {
+		siblings = siblings[:len(siblings)-1]
+	}
+	proof, err := merkletree.NewProofFromData(true, siblings, nil)
+	require.NoError(t, err)
+	root, err := merkletree.NewHashFromString(in.IssuerAuthClaimsTreeRoot)
+	require.NoError(t, err)
+	hi, hv, err := in.IssuerAuthClaim.HiHv()
+	require.NoError(t, err)
+	return merkletree.VerifyProof(root, proof, hi, hv)
+}
+
+func Test_ClaimSlots(t *testing.T) {
+	slots := []struct {
+		Slot int
+		Name string
+	}{
+		{utils.SlotIndexB, "index_b"},
+		{utils.SlotValueA, "value_a"},
+		{utils.SlotValueB, "value_b"},
+	}
+	for _, s := range slots {
+		slot := s.Slot
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generate(t, testCase{
+				Desc:                "User == Subject. Claim non merklized claim. Queried value in slot " + strconv.Itoa(slot),
+				FileName:            string(proofType) + "/claim_slot_" + s.Name,
+				LinkNonce:           "0",
+				NullifierSessionID:  "0",
+				Operator:            utils.EQ,
+				IsRevocationChecked: 1,
+				ProofType:           proofType,
+				Claim: func(subject core.ID) (*core.Claim, int) {
+					return utils.NewSlotClaim(t,
+						utils.WithSubject(subject),
+						utils.WithSlot(slot, 10),
+						utils.WithRevocationNonce(1))
+				},
+			})
+		}
+	}
+}
+
+func Test_SubjectPosition(t *testing.T) {
+	other := utils.NewIdentity(t, issuerPK).ID
+	cases := []struct {
+		Desc     string
+		FileName string
+		IsJSONLD bool
+		Options  []utils.ClaimOption
+	}{
+		{
+			"User == Subject. Claim non merklized claim. Subject in value",
+			"claim_subject_in_value", false,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionValue)},
+		},
+		// user doesn't own the claims below, ownership check of the circuit
+		// fails
+		{
+			"Self claim without subject. Ownership check fails",
+			"self_claim", false,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionNone)},
+		},
+		{
+			"Claim issued on other identity. Ownership check fails",
+			"claim_issued_on_other_identity", false,
+			[]utils.ClaimOption{utils.WithSubject(other)},
+		},
+		{
+			"User == Subject. Merklized claim. Subject in value",
+			"claim_subject_in_value_merklized", true,
+			[]utils.ClaimOption{utils.WithSubjectPosition(core.IDPositionValue)},
+		},
+	}
+	for _, c := range cases {
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generate(t, testCase{
+				Desc:                c.Desc,
+				FileName:            string(proofType) + "/" + c.FileName,
+				LinkNonce:           "0",
+				NullifierSessionID:  "0",
+				Operator:            utils.EQ,
+				IsRevocationChecked: 1,
+				IsJSONLD:            c.IsJSONLD,
+				ProofType:           proofType,
+				ClaimOptions:        c.Options,
+			})
+		}
+	}
+}
+
+func Test_Expiration(t *testing.T) {
+	cases := []struct {
+		Expiration utils.Expiration
+		Desc       string
+	}{
+		{utils.ExpirationNone, "Claim without expiration date"},
+		{utils.ExpirationFuture, "Claim expires after the timestamp"},
+		{utils.ExpirationNow, "Claim expires at the timestamp"},
+		{utils.ExpirationPast, "Claim expired before the timestamp (expected to fail)"},
+	}
+	for _, c := range cases {
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generate(t, testCase{
+				Desc:                c.Desc,
+				FileName:            string(proofType) + "/expiration_" + c.Expiration.String(),
+				LinkNonce:           "0",
+				NullifierSessionID:  "0",
+				Operator:            utils.EQ,
+				IsRevocationChecked: 1,
+				ProofType:           proofType,
+				Expiration:          c.Expiration,
+			})
+		}
+	}
+}
+
+func Test_TypedQueryValues(t *testing.T) {
+	cases := []struct {
+		Desc     string
+		FileName string
+		Field    string
+		Operator int
+		Value    []any
+	}{
+		{
+			"residentSince > 2010-01-01", "typed_date_gt",
+			"https://w3id.org/citizenship#residentSince", utils.GT,
+			[]any{time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			"residentSince between 2010-01-01 and 2020-01-01", "typed_date_between",
+			"https://w3id.org/citizenship#residentSince", utils.BETWEEN,
+			[]any{"2010-01-01", "2020-01-01"},
+		},
+		{
+			"birthDate < 1960-01-01", "typed_birth_date_lt",
+			"http://schema.org/birthDate", utils.LT,
+			[]any{time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			`gender IN ["Male", "Female"]`, "typed_string_in",
+			"http://schema.org/gender", utils.IN,
+			[]any{"Male", "Female"},
+		},
+		{
+			`gender NIN ["Female"]`, "typed_string_nin",
+			"http://schema.org/gender", utils.NIN,
+			[]any{"Female"},
+		},
+		{
+			`gender == "Female" (expected to fail)`, "typed_string_eq_failed",
+			"http://schema.org/gender", utils.EQ,
+			[]any{"Female"},
+		},
+	}
+	for _, c := range cases {
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generate(t, testCase{
+				Desc:                "Typed query value. " + c.Desc,
+				FileName:            string(proofType) + "/" + c.FileName,
+				LinkNonce:           "0",
+				NullifierSessionID:  "0",
+				Operator:            c.Operator,
+				IsRevocationChecked: 1,
+				IsJSONLD:            true,
+				ProofType:           proofType,
+				Field:               c.Field,
+				TypedQueryValue:     c.Value,
+			})
+		}
+	}
+}
+
+var proofCases = []struct {
+	ProofCase utils.ProofCase
+	Name      string
+}{
+	{utils.ProofInclusion, "inclusion"},
+	{utils.ProofNonInclusionAux, "non_inclusion_aux"},
+	{utils.ProofNonInclusionEmpty, "non_inclusion_empty"},
+}
+
+func Test_RevocationProofCases(t *testing.T) {
+	for _, c := range proofCases {
+		// claim in revocation tree is revoked, so it's not checked
+		isRevocationChecked := 1
+		if c.ProofCase == utils.ProofInclusion {
+			isRevocationChecked = 0
+		}
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generate(t, testCase{
+				Desc:                "User == Subject. Claim non merklized claim",
+				FileName:            string(proofType) + "/revocation_proof_" + c.Name,
+				LinkNonce:           "0",
+				NullifierSessionID:  "0",
+				Operator:            utils.EQ,
+				IsRevocationChecked: isRevocationChecked,
+				ProofType:           proofType,
+				RevocationProof:     c.ProofCase,
+			})
+		}
+	}
+}
+
+func Test_ClaimPathProofCases(t *testing.T) {
+	for _, c := range proofCases {
+		// $exists == false checks non-inclusion of the path
+		exists := "0"
+		if c.ProofCase == utils.ProofInclusion {
+			exists = "1"
+		}
+		for _, proofType := range []ProofType{Mtp, Sig} {
+			generate(t, testCase{
+				Desc:                "User == Subject. Merklized claim. Exists operator",
+				FileName:            string(proofType) + "/claim_path_proof_" + c.Name,
+				LinkNonce:           "0",
+				NullifierSessionID:  "0",
+				Operator:            utils.EXISTS,
+				QueryValue:          func(*big.Int) []string { return []string{exists} },
+				IsRevocationChecked: 1,
+				IsJSONLD:            true,
+				ProofType:           proofType,
+				ClaimPathProof:      c.ProofCase,
+			})
+		}
+	}
+}
+
+func Test_OnchainIssuer(t *testing.T) {
+	cases := []struct {
+		Desc                string
+		FileName            string
+		IsRevoked           bool
+		IsRevocationChecked int
+		IsJSONLD            bool
+	}{
+		{"On-chain issuer. Claim non merklized claim", "onchain_issuer", false, 1, false},
+		{"On-chain issuer. Revoked claim without revocation check", "onchain_issuer_revoked_without_revocation_check", true, 0, false},
+		{"On-chain issuer. Merklized claim", "onchain_issuer_merklized", false, 1, true},
+	}
+	for _, c := range cases {
+		generate(t, testCase{
+			Desc:                c.Desc,
+			FileName:            string(Mtp) + "/" + c.FileName,
+			LinkNonce:           "0",
+			NullifierSessionID:  "0",
+			Operator:            utils.EQ,
+			IsRevoked:           c.IsRevoked,
+			IsRevocationChecked: c.IsRevocationChecked,
+			IsJSONLD:            c.IsJSONLD,
+			ProofType:           Mtp,
+			IsOnchainIssuer:     true,
+		})
+	}
+}
+
+// Test_NullifierMatrix generates vectors of the same claim schema proved to
+// different verifiers in different sessions. Nullifier is the same for the
+// same user, subject profile, schema, verifier and session.
+func Test_NullifierMatrix(t *testing.T) {
+	otherVerifier, err := core.ParseDIDFromID(utils.NewIdentity(t, issuerPK).ID)
+	require.NoError(t, err)
+	otherClaim := func(subject core.ID) (*core.Claim, int) {
+		return utils.DefaultUserClaim(t, subject, big.NewInt(11)), 2
+	}
+
+	cases := []struct {
+		Name string
+		Desc string
+		tc   testCase
+		// SameAsBase is true if nullifier is the same as of the base case
+		SameAsBase bool
+	}{
+		{"base", "Nullifier. Claim issued on subject profile, session 123",
+			testCase{IsUserIDProfile: true, IsSubjectIDProfile: true}, true},
+		{"other_claim", "Nullifier. Other claim of the same schema",
+			testCase{IsUserIDProfile: true, IsSubjectIDProfile: true, Claim: otherClaim}, true},
+		{"user_genesis", "Nullifier. Proved by user genesis ID",
+			testCase{IsUserIDProfile: false, IsSubjectIDProfile: true}, true},
+		{"other_subject_profile", "Nullifier. Claim issued on other subject profile",
+			testCase{IsUserIDProfile: true, IsSubjectIDProfile: true, SubjectProfileNonce: big.NewInt(1000)}, false},
+		{"other_session", "Nullifier. Session 124",
+			testCase{IsUserIDProfile: true, IsSubjectIDProfile: true, NullifierSessionID: "124"}, false},
+		{"other_verifier", "Nullifier. Verifier " + otherVerifier.String(),
+			testCase{IsUserIDProfile: true, IsSubjectIDProfile: true, VerifierDID: otherVerifier.String()}, false},
+		{"subject_genesis", "Nullifier. Claim issued on user genesis ID, nullifier is 0",
+			testCase{IsUserIDProfile: true, IsSubjectIDProfile: false}, false},
+	}
+
+	for _, proofType := range []ProofType{Mtp, Sig} {
+		var base string
+		for _, c := range cases {
+			tc := c.tc
+			tc.Desc = c.Desc
+			tc.FileName = string(proofType) + "/nullifier_" + c.Name
+			tc.LinkNonce = "0"
+			if tc.NullifierSessionID == "" {
+				tc.NullifierSessionID = "123"
+			}
+			tc.Operator = utils.EQ
+			tc.IsRevocationChecked = 1
+			tc.ProofType = proofType
+
+			nullifier := generate(t, tc).Out.Nullifier
+			if base == "" {
+				base = nullifier
+				require.NotEqual(t, "0", base)
+			}
+			if c.SameAsBase {
+				require.Equal(t, base, nullifier, c.Name)
+			} else {
+				require.NotEqual(t, base, nullifier, c.Name)
+			}
+		}
+	}
+}
+
 func generateTestData(t *testing.T, desc string, isUserIDProfile, isSubjectIDProfile bool,
 	linkNonce string, fileName string, proofType ProofType) {
 	generateTestDataWithOperatorAndRevCheck(t, desc, isUserIDProfile, isSubjectIDProfile, linkNonce, "0", fileName, utils.EQ, nil, false, 1, false, false, proofType)
@@ -303,52 +659,173 @@ func generateJSONLDTestData(t *testing.T, desc string, isUserIDProfile, isSubjec
 
 func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserIDProfile, isSubjectIDProfile bool,
 	linkNonce, nullifierSessionID, fileName string, operator int, value *[]string, isRevoked bool, isRevocationChecked int, isJSONLD bool, isZeroSubjClaim bool, testProofType ProofType) {
-	var err error
-
-	valueInput := []string{"10"}
-	if value != nil {
-		valueInput = *value
+	tc := testCase{
+		Desc:                desc,
+		FileName:            fileName,
+		IsUserIDProfile:     isUserIDProfile,
+		IsSubjectIDProfile:  isSubjectIDProfile,
+		LinkNonce:           linkNonce,
+		NullifierSessionID:  nullifierSessionID,
+		Operator:            operator,
+		IsRevoked:           isRevoked,
+		IsRevocationChecked: isRevocationChecked,
+		IsJSONLD:            isJSONLD,
+		IsZeroSubjClaim:     isZeroSubjClaim,
+		ProofType:           testProofType,
 	}
+	// JSON-LD vectors always query the actual value of the field
+	if value != nil && !isJSONLD {
+		tc.QueryValue = func(*big.Int) []string { return *value }
+	}
+	generate(t, tc)
+}
 
-	valueArrSize := len(valueInput)
+// testCase describes credentialAtomicQueryV3 test vector
+type testCase struct {
+	Desc               string
+	FileName           string
+	IsUserIDProfile    bool
+	IsSubjectIDProfile bool
+	LinkNonce          string
+	NullifierSessionID string
+	Operator           int
+	// QueryValue returns query value for the actual value of the queried
+	// field, if nil the actual value is queried
+	QueryValue          func(fieldValue *big.Int) []string
+	IsRevoked           bool
+	IsRevocationChecked int
+	IsJSONLD            bool
+	IsZeroSubjClaim     bool
+	ProofType           ProofType
+
+	// Optional overrides of the default data, used by random scenarios
+	User, Issuer *utils.IdentityTest
+	// ProfileNonce is used if IsUserIDProfile is set
+	ProfileNonce *big.Int
+	// SubjectProfileNonce is used if IsSubjectIDProfile is set
+	SubjectProfileNonce *big.Int
+	// Claim returns non-merklized claim issued on the subject and the index
+	// of the queried slot
+	Claim func(subject core.ID) (*core.Claim, int)
+	// Clock sets query timestamp, utils.DefaultClock by default
+	Clock utils.Clock
+	// Expiration sets expiration date of the default claim relative to the
+	// clock
+	Expiration utils.Expiration
+	// ProofDepth extends issuer claim and non-revocation proofs to the depth
+	ProofDepth int
+	// ClaimOptions override options of the default claim, e.g. subject
+	// position
+	ClaimOptions []utils.ClaimOption
+	// RevocationProof and ClaimPathProof force the case of the issuer claim
+	// non-revocation proof and of the JSON-LD claim path proof, the forced
+	// case is added to the description
+	RevocationProof, ClaimPathProof utils.ProofCase
+	// Field is IRI of the queried credential subject field of merklized
+	// claim, residentSince by default
+	Field string
+	// TypedQueryValue is the query value of merklized claim as typed values,
+	// they are encoded by the datatype of the field. Overrides QueryValue.
+	TypedQueryValue []any
+	// IsOnchainIssuer issues the claim by on-chain issuer of ethAddress, the
+	// issuer inputs are imported from the credential with on-chain status.
+	// Supported by MTP proofs only.
+	IsOnchainIssuer bool
+	// VerifierDID is DID of the verifier, utils.DefaultVerifierDID by
+	// default
+	VerifierDID string
+}
 
-	valueInput = utils.PrepareStrArray(valueInput, 64)
+func generate(t *testing.T, tc testCase) TestData {
+	var err error
 
-	user := utils.NewIdentity(t, userPK)
-	issuer := utils.NewIdentity(t, issuerPK)
+	desc, fileName := tc.Desc, tc.FileName
+	isUserIDProfile, isSubjectIDProfile := tc.IsUserIDProfile, tc.IsSubjectIDProfile
+	linkNonce, nullifierSessionID := tc.LinkNonce, tc.NullifierSessionID
+	operator, testProofType := tc.Operator, tc.ProofType
+	isRevoked, isRevocationChecked := tc.IsRevoked, tc.IsRevocationChecked
+	isJSONLD, isZeroSubjClaim := tc.IsJSONLD, tc.IsZeroSubjClaim
+
+	user, issuer := tc.User, tc.Issuer
+	if user == nil {
+		user = utils.NewIdentity(t, userPK)
+	}
+	var onchainIssuer *onchain.Issuer
+	if tc.IsOnchainIssuer {
+		require.Equal(t, Mtp, testProofType, "on-chain issuer has no BJJ auth claim")
+		require.Nil(t, issuer)
+		onchainIssuer = onchain.NewIssuer(t, ethAddress, onchain.NewRegistry(t))
+		issuer = onchainIssuer.IdentityTest
+	}
+	if issuer == nil {
+		issuer = utils.NewIdentity(t, issuerPK)
+	}
+	clock := tc.Clock
+	if clock == nil {
+		clock = utils.DefaultClock
+	}
+	timestamp := strconv.FormatInt(clock.Now().Unix(), 10)
+	claimOptions := tc.ClaimOptions
+	if tc.Expiration != utils.ExpirationDefault {
+		claimOptions = append(claimOptions, utils.WithRelativeExpiration(clock, tc.Expiration))
+		desc += ". Expiration: " + tc.Expiration.String()
+	}
 
 	userProfileID := user.ID
-	nonce := big.NewInt(0)
 	if isUserIDProfile {
-		nonce = big.NewInt(10)
-		userProfileID, err = core.ProfileID(user.ID, nonce)
-		require.NoError(t, err)
+		profileNonce := big.NewInt(utils.UserProfileNonce)
+		if tc.ProfileNonce != nil {
+			profileNonce = tc.ProfileNonce
+		}
+		userProfileID = user.AddProfile(t, utils.UserProfile, profileNonce)
 	}
 
 	subjectID := user.ID
-	nonceSubject := big.NewInt(0)
 	if isSubjectIDProfile {
-		nonceSubject = big.NewInt(999)
-		subjectID, err = core.ProfileID(user.ID, nonceSubject)
-		require.NoError(t, err)
+		subjectProfileNonce := big.NewInt(utils.SubjectProfileNonce)
+		if tc.SubjectProfileNonce != nil {
+			subjectProfileNonce = tc.SubjectProfileNonce
+		}
+		subjectID = user.AddProfile(t, utils.SubjectProfile, subjectProfileNonce)
 	}
+	nonce := user.ProfileNonce(t, userProfileID)
+	nonceSubject := user.ProfileNonce(t, subjectID)
 
 	var claim *core.Claim
 	var mz *merklize.Merklizer
 	var claimPathMtp []string
 	var claimPathMtpNoAux, claimPathMtpAuxHi, claimPathMtpAuxHv, claimPathKey, claimPathValue, merklized string
-	var pathKey *big.Int
+	var pathKey, fieldValue *big.Int
+	var typedQueryValue []string
+	claimSchema := "180410020913331409885634153623124536270"
+	claimSlotIndex := 2
 
 	if isJSONLD {
-		mz, claim = utils.DefaultJSONUserClaim(t, subjectID)
+		mz, claim = utils.DefaultJSONUserClaim(t, subjectID, claimOptions...)
+		field := "https://w3id.org/citizenship#residentSince"
+		if tc.Field != "" {
+			field = tc.Field
+		}
 		path, err := merklize.NewPath(
-			"https://www.w3.org/2018/credentials#credentialSubject",
-			"https://w3id.org/citizenship#residentSince")
+			"https://www.w3.org/2018/credentials#credentialSubject", field)
 		require.NoError(t, err)
+		if tc.TypedQueryValue != nil {
+			typedQueryValue = utils.MerklizedQueryValues(t, mz, path, tc.TypedQueryValue...)
+		}
+		if tc.ClaimPathProof != utils.ProofAny {
+			if tc.ClaimPathProof != utils.ProofInclusion {
+				path = utils.MissingPath(t, mz, tc.ClaimPathProof)
+			}
+			desc += ". Claim path proof: " + tc.ClaimPathProof.String()
+		}
 		jsonP, value, err := mz.Proof(context.Background(), path)
 		require.NoError(t, err)
-		valueKey, err := value.MtEntry()
-		require.NoError(t, err)
+		// value of missing path is 0
+		valueKey := big.NewInt(0)
+		if value != nil {
+			valueKey, err = value.MtEntry()
+			require.NoError(t, err)
+		}
 		claimPathValue = valueKey.String()
 
 		var claimJSONLDProofAux utils.NodeAuxValue
@@ -360,7 +837,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		require.NoError(t, err)
 		claimPathKey = pathKey.String()
 
-		valueInput = utils.PrepareStrArray([]string{claimPathValue}, 64)
+		fieldValue = valueKey
 		merklized = "1"
 
 	} else {
@@ -368,7 +845,14 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		if isZeroSubjClaim {
 			subjValue = big.NewInt(0)
 		}
-		claim = utils.DefaultUserClaim(t, subjectID, subjValue)
+		require.Nil(t, tc.TypedQueryValue, "typed query values are supported by merklized claims")
+		claim = utils.DefaultUserClaim(t, subjectID, subjValue, claimOptions...)
+		fieldValue = big.NewInt(10)
+		if tc.Claim != nil {
+			claim, claimSlotIndex = tc.Claim(subjectID)
+			fieldValue = claim.RawSlotsAsInts()[claimSlotIndex]
+			claimSchema = claim.GetSchemaHash().BigInt().String()
+		}
 		claimPathMtp = utils.PrepareStrArray([]string{}, 32)
 		claimPathMtpNoAux = "0"
 		claimPathMtpAuxHi = "0"
@@ -379,13 +863,32 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		pathKey = big.NewInt(0)
 	}
 
+	valueInput := []string{fieldValue.String()}
+	if tc.QueryValue != nil {
+		valueInput = tc.QueryValue(fieldValue)
+	}
+	if typedQueryValue != nil {
+		valueInput = typedQueryValue
+	}
+	valueArrSize := len(valueInput)
+	valueInput = utils.PrepareStrArray(valueInput, 64)
+
 	if isRevoked {
 		revNonce := claim.GetRevocationNonce()
 		revNonceBigInt := new(big.Int).SetUint64(revNonce)
 		issuer.Ret.Add(context.Background(), revNonceBigInt, big.NewInt(0))
 	}
+	if tc.ProofDepth > 0 {
+		issuer.DeepenRevocationPath(t, claim, tc.ProofDepth)
+	}
+	// forced after the tree is filled, so the case is the one of the proof
+	if tc.RevocationProof != utils.ProofAny {
+		revNonce := new(big.Int).SetUint64(claim.GetRevocationNonce())
+		utils.ForceProofCase(t, issuer.Ret, revNonce, big.NewInt(0), tc.RevocationProof)
+		desc += ". Revocation proof: " + tc.RevocationProof.String()
+	}
 
-	var issuerClaimMtp, issuerAuthClaimMtp []string
+	var issuerClaimMtp, issuerAuthClaimMtp, issuerAuthClaimNonRevMtp []string
 	var issuerClaimClaimsTreeRoot, issuerClaimRevTreeRoot, issuerClaimRootsTreeRoot *merkletree.Hash
 	var issuerClaimSignatureR8X, issuerClaimSignatureR8Y, issuerClaimSignatureS,
 		issuerAuthClaimNonRevMtpAuxHi, issuerAuthClaimNonRevMtpAuxHv, issuerAuthClaimNonRevMtpNoAux,
@@ -397,7 +900,8 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		// Sig claim
 		claimSig := issuer.SignClaim(t, claim)
 		var issuerAuthClaimNodeAux utils.NodeAuxValue
-		issuerAuthClaimMtp, issuerAuthClaimNodeAux = issuer.ClaimRevMTP(t, issuer.AuthClaim)
+		issuerAuthClaimMtp, _ = issuer.ClaimMTP(t, issuer.AuthClaim)
+		issuerAuthClaimNonRevMtp, issuerAuthClaimNodeAux = issuer.ClaimRevMTP(t, issuer.AuthClaim)
 
 		issuerClaimMtp = utils.PrepareStrArray([]string{}, 40)
 		issuerClaimClaimsTreeRoot = &merkletree.HashZero
@@ -422,11 +926,52 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 		issuerAuthState = issuer.State(t).String()
 
-		slotIndex = 2
+		slotIndex = claimSlotIndex
 
 		proofType = "1"
+	} else if onchainIssuer != nil {
+		if tc.ProofDepth > 0 {
+			issuer.DeepenClaimPath(t, claim, tc.ProofDepth)
+		}
+		onchainIssuer.Issue(t, claim)
+		in := onchainIssuer.IssuerInputs(t, claim)
+		issuerClaimMtp = in.IssuerClaimMtp
+		issuerClaimIdenState = in.IssuerClaimIdenState
+
+		issuerClaimClaimsTreeRoot = in.IssuerClaimClaimsTreeRoot
+		issuerClaimRevTreeRoot = in.IssuerClaimRevTreeRoot
+		issuerClaimRootsTreeRoot = in.IssuerClaimRootsTreeRoot
+
+		issuerClaimSignatureR8X = in.IssuerClaimSignatureR8X
+		issuerClaimSignatureR8Y = in.IssuerClaimSignatureR8Y
+		issuerClaimSignatureS = in.IssuerClaimSignatureS
+
+		issuerAuthClaimNonRevMtpAuxHi = in.IssuerAuthClaimNonRevMtpAuxHi
+		issuerAuthClaimNonRevMtpAuxHv = in.IssuerAuthClaimNonRevMtpAuxHv
+		issuerAuthClaimNonRevMtpNoAux = in.IssuerAuthClaimNonRevMtpNoAux
+
+		issuerAuthClaimMtp = in.IssuerAuthClaimMtp
+		issuerAuthClaimNonRevMtp = in.IssuerAuthClaimNonRevMtp
+
+		issuerAuthClaim = in.IssuerAuthClaim
+
+		issuerAuthClaimsTreeRoot = in.IssuerAuthClaimsTreeRoot
+		issuerAuthRevTreeRoot = in.IssuerAuthRevTreeRoot
+		issuerAuthRootsTreeRoot = in.IssuerAuthRootsTreeRoot
+
+		issuerAuthState = in.IssuerAuthState
+
+		slotIndex = claimSlotIndex
+		proofType = in.ProofType
+
+		// non-revocation inputs of the latest published state are the same
+		// as of the issuer trees below
+		require.Equal(t, issuer.State(t).String(), in.IssuerClaimNonRevState)
 	} else {
 		issuer.AddClaim(t, claim)
+		if tc.ProofDepth > 0 {
+			issuer.DeepenClaimPath(t, claim, tc.ProofDepth)
+		}
 		issuerClaimMtp, _ = issuer.ClaimMTP(t, claim)
 		issuerClaimIdenState = issuer.State(t).String()
 
@@ -443,6 +988,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		issuerAuthClaimNonRevMtpNoAux = "0"
 
 		issuerAuthClaimMtp = utils.PrepareStrArray([]string{}, 40)
+		issuerAuthClaimNonRevMtp = issuerAuthClaimMtp
 
 		issuerAuthClaim = &core.Claim{}
 
@@ -452,7 +998,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 		issuerAuthState = "0"
 
-		slotIndex = 2
+		slotIndex = claimSlotIndex
 		proofType = "2"
 	}
 
@@ -460,6 +1006,19 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 	requestID := big.NewInt(23)
 
+	verifierDID := tc.VerifierDID
+	if verifierDID == "" {
+		verifierDID = utils.DefaultVerifierDID
+	}
+	claimSchemaInt, ok := big.NewInt(0).SetString(claimSchema, 10)
+	require.True(t, ok)
+	nullifierSessionIDInt, ok := big.NewInt(0).SetString(nullifierSessionID, 10)
+	require.True(t, ok)
+	nullifierScenario := utils.NewNullifierScenario(user, claimSchemaInt).
+		WithSubject(subjectID).
+		WithVerifier(verifierDID).
+		WithSession(nullifierSessionIDInt)
+
 	inputs := Inputs{
 		RequestID:                       requestID.String(),
 		UserGenesisID:                   user.ID.BigInt().String(),
@@ -480,7 +1039,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		IssuerClaimNonRevMtpAuxHi:       issuerClaimNonRevAux.Key,
 		IssuerClaimNonRevMtpAuxHv:       issuerClaimNonRevAux.Value,
 		IssuerClaimNonRevMtpNoAux:       issuerClaimNonRevAux.NoAux,
-		ClaimSchema:                     "180410020913331409885634153623124536270",
+		ClaimSchema:                     claimSchema,
 		ClaimPathMtp:                    claimPathMtp,
 		ClaimPathMtpNoAux:               claimPathMtpNoAux,
 		ClaimPathMtpAuxHi:               claimPathMtpAuxHi,
@@ -499,7 +1058,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		IssuerClaimSignatureS:         issuerClaimSignatureS,
 		IssuerAuthClaim:               issuerAuthClaim,
 		IssuerAuthClaimMtp:            issuerAuthClaimMtp,
-		IssuerAuthClaimNonRevMtp:      issuerAuthClaimMtp,
+		IssuerAuthClaimNonRevMtp:      issuerAuthClaimNonRevMtp,
 		IssuerAuthClaimNonRevMtpAuxHi: issuerAuthClaimNonRevMtpAuxHi,
 		IssuerAuthClaimNonRevMtpAuxHv: issuerAuthClaimNonRevMtpAuxHv,
 		IssuerAuthClaimNonRevMtpNoAux: issuerAuthClaimNonRevMtpNoAux,
@@ -512,7 +1071,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 
 		ProofType: proofType,
 
-		VerifierID:         "21929109382993718606847853573861987353620810345503358891473103689157378049",
+		VerifierID:         nullifierScenario.VerifierID(t).String(),
 		NullifierSessionID: nullifierSessionID,
 	}
 
@@ -520,29 +1079,10 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 	require.NoError(t, err)
 
 	operatorOutput := "0"
-	nullifier := "0"
-	if inputs.NullifierSessionID != "0" {
-		claimSchema, ok := big.NewInt(0).SetString(inputs.ClaimSchema, 10)
-		require.True(t, ok)
-
-		verifierID, ok := big.NewInt(0).SetString(inputs.VerifierID, 10)
-		require.True(t, ok)
-
-		nullifierSessionID_, ok := big.NewInt(0).SetString(inputs.NullifierSessionID, 10)
-		require.True(t, ok)
-
-		nullifier, err = utils.CalculateNullify(
-			user.ID.BigInt(),
-			nonceSubject,
-			claimSchema,
-			verifierID,
-			nullifierSessionID_,
-		)
-		require.NoError(t, err)
-	}
+	nullifier := nullifierScenario.Nullifier(t)
 
 	if operator == utils.SD {
-		operatorOutput = big.NewInt(10).String()
+		operatorOutput = fieldValue.String()
 	}
 
 	var issuerState string
@@ -559,7 +1099,7 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		UserID:                 userProfileID.BigInt().String(),
 		IssuerID:               issuer.ID.BigInt().String(),
 		IssuerClaimNonRevState: issuer.State(t).String(),
-		ClaimSchema:            "180410020913331409885634153623124536270",
+		ClaimSchema:            claimSchema,
 		SlotIndex:              strconv.Itoa(slotIndex),
 		ClaimPathKey:           claimPathKey,
 		Operator:               operator,
@@ -577,14 +1117,17 @@ func generateTestDataWithOperatorAndRevCheck(t *testing.T, desc string, isUserID
 		Nullifier:              nullifier,
 	}
 
-	jsonData, err := json.Marshal(TestData{
+	testData := TestData{
 		desc,
 		inputs,
 		out,
-	})
+	}
+	jsonData, err := json.Marshal(testData)
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(jsonData))
+	utils.SaveSnarkjsFiles(t, "credentialAtomicQueryV3", fileName, string(jsonData))
+	return testData
 }
 
 func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubjectIDProfile bool, desc,
@@ -594,22 +1137,19 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 
 	user := utils.NewIdentity(t, userPK)
 	issuer := utils.NewIdentity(t, issuerPK)
+	timestamp := strconv.FormatInt(utils.DefaultClock.Now().Unix(), 10)
 
 	userProfileID := user.ID
-	nonce := big.NewInt(0)
 	if isUserIDProfile {
-		nonce = big.NewInt(10)
-		userProfileID, err = core.ProfileID(user.ID, nonce)
-		require.NoError(t, err)
+		userProfileID = user.AddProfile(t, utils.UserProfile, big.NewInt(utils.UserProfileNonce))
 	}
 
 	subjectID := user.ID
-	nonceSubject := big.NewInt(0)
 	if isSubjectIDProfile {
-		nonceSubject = big.NewInt(999)
-		subjectID, err = core.ProfileID(user.ID, nonceSubject)
-		require.NoError(t, err)
+		subjectID = user.AddProfile(t, utils.SubjectProfile, big.NewInt(utils.SubjectProfileNonce))
 	}
+	nonce := user.ProfileNonce(t, userProfileID)
+	nonceSubject := user.ProfileNonce(t, subjectID)
 
 	mz, claim := utils.DefaultJSONUserClaim(t, subjectID)
 
@@ -632,10 +1172,14 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 
 	issuerClaimNonRevMtp, issuerClaimNonRevAux := issuer.ClaimRevMTP(t, claim)
 
-	issuerAuthClaimMtp, issuerAuthClaimNodeAux := issuer.ClaimRevMTP(t, issuer.AuthClaim)
+	issuerAuthClaimMtp, _ := issuer.ClaimMTP(t, issuer.AuthClaim)
+	issuerAuthClaimNonRevMtp, issuerAuthClaimNodeAux := issuer.ClaimRevMTP(t, issuer.AuthClaim)
 
 	requestID := big.NewInt(23)
 
+	verifierID, err := utils.VerifierID(utils.DefaultVerifierDID)
+	require.NoError(t, err)
+
 	inputs := Inputs{
 		RequestID:                       requestID.String(),
 		UserGenesisID:                   user.ID.BigInt().String(),
@@ -656,7 +1200,7 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 		IssuerClaimSignatureS:           claimSig.S.String(),
 		IssuerAuthClaim:                 issuer.AuthClaim,
 		IssuerAuthClaimMtp:              issuerAuthClaimMtp,
-		IssuerAuthClaimNonRevMtp:        issuerAuthClaimMtp,
+		IssuerAuthClaimNonRevMtp:        issuerAuthClaimNonRevMtp,
 		IssuerAuthClaimNonRevMtpAuxHi:   issuerAuthClaimNodeAux.Key,
 		IssuerAuthClaimNonRevMtpAuxHv:   issuerAuthClaimNodeAux.Value,
 		IssuerAuthClaimNonRevMtpNoAux:   issuerAuthClaimNodeAux.NoAux,
@@ -692,7 +1236,7 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 
 		ProofType: "1",
 
-		VerifierID:         "21929109382993718606847853573861987353620810345503358891473103689157378049",
+		VerifierID:         verifierID.String(),
 		NullifierSessionID: "0",
 	}
 
@@ -729,4 +1273,5 @@ func generateJSONLD_NON_INCLUSION_TestData(t *testing.T, isUserIDProfile, isSubj
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(jsonData))
+	utils.SaveSnarkjsFiles(t, "credentialAtomicQueryV3", fileName, string(jsonData))
 }
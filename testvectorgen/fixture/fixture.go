@@ -0,0 +1,210 @@
+// Package fixture builds test fixtures for the contracts repository: proven
+// test vectors with public signals and Solidity verifier calldata.
+package fixture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"test/groth16"
+	"test/prover"
+	"test/pubsignals"
+	"test/snarkjs"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Fixture is a proven test vector in the format of contracts test data
+type Fixture struct {
+	Inputs     json.RawMessage `json:"inputs"`
+	PubSignals []string        `json:"pub_signals"`
+	Proof      *prover.Proof   `json:"proof"`
+	// A, B, C are proof points in the order Solidity verifier expects them
+	A [2]string    `json:"a"`
+	B [2][2]string `json:"b"`
+	C [2]string    `json:"c"`
+	// Calldata is ABI encoded
+	// (uint256[] inputs, uint256[2] a, uint256[2][2] b, uint256[2] c)
+	Calldata string `json:"calldata"`
+	// MsgSender is the address the proof must be submitted from, set for
+	// proofs of identities authenticated by Ethereum address
+	MsgSender string `json:"msg_sender,omitempty"`
+	// ChallengeSignature is the signature of the challenge by the key of
+	// MsgSender, hex encoded [R || S || V] for ecrecover
+	ChallengeSignature string `json:"challenge_signature,omitempty"`
+}
+
+type testVector struct {
+	MsgSender          string `json:"msgSender"`
+	ChallengeSignature string `json:"challengeSignature"`
+}
+
+// Build proves inputs of the test vector for the circuit and returns the
+// fixture. Public signals returned by the prover are checked against the
+// expected outputs of the vector, if the prover doesn't return public signals
+// expected ones are used. msgSender and challengeSignature of the vector are
+// copied to the fixture.
+func Build(ctx context.Context, p prover.Prover, circuit, zkey string, vector []byte) (*Fixture, error) {
+	c, err := snarkjs.FindCircuit(circuit)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := pubsignals.Get(circuit)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, err := c.Input(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := layout.FromVector(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, public, err := p.Prove(ctx, zkey, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prove: %w", circuit, err)
+	}
+	if public == nil {
+		public = make([]string, len(expected))
+		for i := range expected {
+			public[i] = expected[i].String()
+		}
+	}
+	if err = checkPublic(layout, expected, public); err != nil {
+		return nil, err
+	}
+
+	f, err := New(inputs, public, proof)
+	if err != nil {
+		return nil, err
+	}
+	var tv testVector
+	if err = json.Unmarshal(vector, &tv); err != nil {
+		return nil, err
+	}
+	f.MsgSender = tv.MsgSender
+	f.ChallengeSignature = tv.ChallengeSignature
+	return f, nil
+}
+
+// New returns fixture for the proof and public signals
+func New(inputs json.RawMessage, public []string, proof *prover.Proof) (*Fixture, error) {
+	if len(proof.A) < 2 || len(proof.B) < 2 || len(proof.B[0]) < 2 ||
+		len(proof.B[1]) < 2 || len(proof.C) < 2 {
+		return nil, fmt.Errorf("invalid proof")
+	}
+
+	f := &Fixture{
+		Inputs:     inputs,
+		PubSignals: public,
+		Proof:      proof,
+		A:          [2]string{proof.A[0], proof.A[1]},
+		// Solidity verifier expects imaginary part of G2 coordinates first
+		B: [2][2]string{
+			{proof.B[0][1], proof.B[0][0]},
+			{proof.B[1][1], proof.B[1][0]},
+		},
+		C: [2]string{proof.C[0], proof.C[1]},
+	}
+
+	calldata, err := f.calldata()
+	if err != nil {
+		return nil, err
+	}
+	f.Calldata = hexutil.Encode(calldata)
+
+	return f, nil
+}
+
+// Verify checks the fixture proof for its public signals with the
+// verification key
+func (f *Fixture) Verify(vk *groth16.VerificationKey) error {
+	public, err := toBigInts(f.PubSignals...)
+	if err != nil {
+		return err
+	}
+	return groth16.Verify(vk, f.Proof, public)
+}
+
+func (f *Fixture) calldata() ([]byte, error) {
+	inputs, err := toBigInts(f.PubSignals...)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toBigInts(f.A[:]...)
+	if err != nil {
+		return nil, err
+	}
+	b0, err := toBigInts(f.B[0][:]...)
+	if err != nil {
+		return nil, err
+	}
+	b1, err := toBigInts(f.B[1][:]...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := toBigInts(f.C[:]...)
+	if err != nil {
+		return nil, err
+	}
+
+	return calldataArgs.Pack(
+		inputs,
+		[2]*big.Int{a[0], a[1]},
+		[2][2]*big.Int{{b0[0], b0[1]}, {b1[0], b1[1]}},
+		[2]*big.Int{c[0], c[1]},
+	)
+}
+
+var calldataArgs = abi.Arguments{
+	{Type: mustType("uint256[]")},
+	{Type: mustType("uint256[2]")},
+	{Type: mustType("uint256[2][2]")},
+	{Type: mustType("uint256[2]")},
+}
+
+func mustType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+func toBigInts(values ...string) ([]*big.Int, error) {
+	res := make([]*big.Int, len(values))
+	for i, v := range values {
+		var ok bool
+		res[i], ok = new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid value %q", v)
+		}
+	}
+	return res, nil
+}
+
+// checkPublic compares public signals returned by the prover with expected
+func checkPublic(layout *pubsignals.Layout, expected []*big.Int, public []string) error {
+	if len(public) != len(expected) {
+		return fmt.Errorf("%s: expected %d public signals, got %d",
+			layout.Circuit, len(expected), len(public))
+	}
+
+	i := 0
+	for _, s := range layout.Signals {
+		for j := 0; j < s.Len(); j, i = j+1, i+1 {
+			if public[i] != expected[i].String() {
+				return fmt.Errorf("%s: public signal %s[%d]: expected %s, got %s",
+					layout.Circuit, s.Name, j, expected[i], public[i])
+			}
+		}
+	}
+	return nil
+}
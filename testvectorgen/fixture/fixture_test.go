@@ -0,0 +1,101 @@
+package fixture
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"test/prover"
+	"test/snarkjs"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+type staticProver struct {
+	public []string
+}
+
+func (p staticProver) Prove(ctx context.Context, zkey string, inputs []byte) (*prover.Proof, []string, error) {
+	proof, _, err := prover.Fake{}.Prove(ctx, zkey, inputs)
+	return proof, p.public, err
+}
+
+func stateTransitionVector(t *testing.T) []byte {
+	c, err := snarkjs.FindCircuit("stateTransitionV3")
+	require.NoError(t, err)
+
+	inputs := map[string]any{}
+	for _, name := range c.Inputs() {
+		inputs[name] = "0"
+	}
+	inputs["userID"] = "1"
+	inputs["oldUserState"] = "2"
+	inputs["newUserState"] = "3"
+	inputs["isOldStateGenesis"] = "1"
+
+	vector, err := json.Marshal(map[string]any{
+		"desc":   "state transition",
+		"inputs": inputs,
+		"expOut": map[string]any{},
+	})
+	require.NoError(t, err)
+	return vector
+}
+
+func Test_Build(t *testing.T) {
+	vector := stateTransitionVector(t)
+
+	f, err := Build(context.Background(), prover.Fake{}, "stateTransitionV3", "", vector)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2", "3", "1"}, f.PubSignals)
+	require.Equal(t, f.Proof.A[:2], f.A[:])
+	require.Equal(t, []string{f.Proof.B[0][1], f.Proof.B[0][0]}, f.B[0][:])
+
+	calldata, err := hexutil.Decode(f.Calldata)
+	require.NoError(t, err)
+	values, err := calldataArgs.Unpack(calldata)
+	require.NoError(t, err)
+	require.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(1)}, values[0])
+	require.Equal(t, f.C[1], values[3].([2]*big.Int)[1].String())
+
+	// fake proofs are deterministic
+	f2, err := Build(context.Background(), prover.Fake{}, "stateTransitionV3", "", vector)
+	require.NoError(t, err)
+	require.Equal(t, f, f2)
+	require.Empty(t, f.MsgSender)
+	require.Empty(t, f.ChallengeSignature)
+}
+
+func Test_BuildMsgSender(t *testing.T) {
+	var vector map[string]any
+	err := json.Unmarshal(stateTransitionVector(t), &vector)
+	require.NoError(t, err)
+	vector["msgSender"] = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+	vector["challengeSignature"] = "0x01"
+	b, err := json.Marshal(vector)
+	require.NoError(t, err)
+
+	f, err := Build(context.Background(), prover.Fake{}, "stateTransitionV3", "", b)
+	require.NoError(t, err)
+	require.Equal(t, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", f.MsgSender)
+
+	out, err := json.Marshal(f)
+	require.NoError(t, err)
+	require.Contains(t, string(out), `"msg_sender":"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"`)
+	require.Contains(t, string(out), `"challenge_signature":"0x01"`)
+}
+
+func Test_BuildPublicMismatch(t *testing.T) {
+	vector := stateTransitionVector(t)
+
+	p := staticProver{public: []string{"1", "2", "4", "1"}}
+	_, err := Build(context.Background(), p, "stateTransitionV3", "", vector)
+	require.EqualError(t, err,
+		"stateTransitionV3: public signal newUserState[0]: expected 3, got 4")
+
+	p = staticProver{public: []string{"1", "2", "3", "1"}}
+	_, err = Build(context.Background(), p, "stateTransitionV3", "", vector)
+	require.NoError(t, err)
+}
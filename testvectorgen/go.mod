@@ -16,7 +16,7 @@ require (
 	github.com/ethereum/go-ethereum v1.13.15
 	github.com/holiman/uint256 v1.2.4 // indirect
 	github.com/mr-tron/base58 v1.2.0 // indirect
-	github.com/piprate/json-gold v0.5.1-0.20230111113000-6ddbe6e6f19f // indirect
+	github.com/piprate/json-gold v0.5.1-0.20230111113000-6ddbe6e6f19f
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/pquerna/cachecontrol v0.2.0 // indirect
@@ -0,0 +1,230 @@
+// Package groth16 verifies snarkjs Groth16 proofs over BN254.
+package groth16
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"test/prover"
+	"test/pubsignals"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// ErrInvalidProof returns when proof doesn't verify
+var ErrInvalidProof = errors.New("invalid proof")
+
+// VerificationKey is a Groth16 verification key in snarkjs
+// verification_key.json format
+type VerificationKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Alpha    []string   `json:"vk_alpha_1"`
+	Beta     [][]string `json:"vk_beta_2"`
+	Gamma    [][]string `json:"vk_gamma_2"`
+	Delta    [][]string `json:"vk_delta_2"`
+	IC       [][]string `json:"IC"`
+}
+
+// LoadVerificationKey reads snarkjs verification_key.json
+func LoadVerificationKey(fileName string) (*VerificationKey, error) {
+	var vk VerificationKey
+	if err := loadJSON(fileName, &vk); err != nil {
+		return nil, err
+	}
+	return &vk, nil
+}
+
+// LoadProof reads snarkjs proof.json
+func LoadProof(fileName string) (*prover.Proof, error) {
+	var proof prover.Proof
+	if err := loadJSON(fileName, &proof); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// LoadPublic reads snarkjs public.json
+func LoadPublic(fileName string) ([]*big.Int, error) {
+	var public []string
+	if err := loadJSON(fileName, &public); err != nil {
+		return nil, err
+	}
+	res := make([]*big.Int, len(public))
+	for i, p := range public {
+		var err error
+		if res[i], err = parseInt(p); err != nil {
+			return nil, fmt.Errorf("public signal %d: %w", i, err)
+		}
+	}
+	return res, nil
+}
+
+func loadJSON(fileName string, v any) error {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Verify checks the proof for the public signals. ErrInvalidProof is returned
+// if pairing check fails.
+func Verify(vk *VerificationKey, proof *prover.Proof, public []*big.Int) error {
+	if vk.Protocol != "" && vk.Protocol != "groth16" {
+		return fmt.Errorf("unsupported protocol %s", vk.Protocol)
+	}
+	if vk.Curve != "" && vk.Curve != "bn128" {
+		return fmt.Errorf("unsupported curve %s", vk.Curve)
+	}
+	if len(vk.IC) != len(public)+1 {
+		return fmt.Errorf("expected %d public signals, got %d", len(vk.IC)-1, len(public))
+	}
+
+	vkX, err := g1Point(vk.IC[0])
+	if err != nil {
+		return fmt.Errorf("IC[0]: %w", err)
+	}
+	for i, p := range public {
+		if p.Sign() < 0 || p.Cmp(bn256.Order) >= 0 {
+			return fmt.Errorf("public signal %d is not in the field", i)
+		}
+		ic, err := g1Point(vk.IC[i+1])
+		if err != nil {
+			return fmt.Errorf("IC[%d]: %w", i+1, err)
+		}
+		vkX.Add(vkX, new(bn256.G1).ScalarMult(ic, p))
+	}
+
+	alpha, err := g1Point(vk.Alpha)
+	if err != nil {
+		return fmt.Errorf("vk_alpha_1: %w", err)
+	}
+	beta, err := g2Point(vk.Beta)
+	if err != nil {
+		return fmt.Errorf("vk_beta_2: %w", err)
+	}
+	gamma, err := g2Point(vk.Gamma)
+	if err != nil {
+		return fmt.Errorf("vk_gamma_2: %w", err)
+	}
+	delta, err := g2Point(vk.Delta)
+	if err != nil {
+		return fmt.Errorf("vk_delta_2: %w", err)
+	}
+
+	a, err := g1Point(proof.A)
+	if err != nil {
+		return fmt.Errorf("pi_a: %w", err)
+	}
+	b, err := g2Point(proof.B)
+	if err != nil {
+		return fmt.Errorf("pi_b: %w", err)
+	}
+	c, err := g1Point(proof.C)
+	if err != nil {
+		return fmt.Errorf("pi_c: %w", err)
+	}
+
+	// e(-A, B) * e(alpha, beta) * e(vkX, gamma) * e(C, delta) == 1
+	ok := bn256.PairingCheck(
+		[]*bn256.G1{new(bn256.G1).Neg(a), alpha, vkX, c},
+		[]*bn256.G2{b, beta, gamma, delta},
+	)
+	if !ok {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// VerifyOutputs checks the proof for public signals of the circuit taken from
+// the sources, e.g. test vector Outputs and Inputs.
+func VerifyOutputs(vk *VerificationKey, proof *prover.Proof, circuit string, sources ...any) error {
+	layout, err := pubsignals.Get(circuit)
+	if err != nil {
+		return err
+	}
+	public, err := layout.ToBigInts(sources...)
+	if err != nil {
+		return err
+	}
+	return Verify(vk, proof, public)
+}
+
+func parseInt(s string) (*big.Int, error) {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	return i, nil
+}
+
+// g1Point returns point from snarkjs projective coordinates [x, y, z], only
+// affine points with z = 1 and point at infinity with z = 0 are supported.
+func g1Point(coords []string) (*bn256.G1, error) {
+	if len(coords) < 2 {
+		return nil, errors.New("invalid G1 point")
+	}
+	buf := make([]byte, 64)
+	if len(coords) < 3 || coords[2] != "0" {
+		if len(coords) == 3 && coords[2] != "1" {
+			return nil, errors.New("G1 point is not affine")
+		}
+		for i := 0; i < 2; i++ {
+			if err := putWord(buf[i*32:], coords[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// g2Point returns point from snarkjs coordinates [[x0, x1], [y0, y1], z],
+// coordinates are marshalled with imaginary part first.
+func g2Point(coords [][]string) (*bn256.G2, error) {
+	if len(coords) < 2 || len(coords[0]) != 2 || len(coords[1]) != 2 {
+		return nil, errors.New("invalid G2 point")
+	}
+	buf := make([]byte, 128)
+	infinity := len(coords) > 2 && len(coords[2]) == 2 &&
+		coords[2][0] == "0" && coords[2][1] == "0"
+	if !infinity {
+		if len(coords) > 2 && (len(coords[2]) != 2 ||
+			coords[2][0] != "1" || coords[2][1] != "0") {
+			return nil, errors.New("G2 point is not affine")
+		}
+		words := []string{coords[0][1], coords[0][0], coords[1][1], coords[1][0]}
+		for i, w := range words {
+			if err := putWord(buf[i*32:], w); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func putWord(buf []byte, s string) error {
+	i, err := parseInt(s)
+	if err != nil {
+		return err
+	}
+	if i.Sign() < 0 || i.BitLen() > 256 {
+		return fmt.Errorf("coordinate %s is out of range", s)
+	}
+	i.FillBytes(buf[:32])
+	return nil
+}
@@ -0,0 +1,155 @@
+package groth16
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"test/prover"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/stretchr/testify/require"
+)
+
+func randScalar(t *testing.T) *big.Int {
+	k, err := rand.Int(rand.Reader, bn256.Order)
+	require.NoError(t, err)
+	return k
+}
+
+func g1Strings(k *big.Int) []string {
+	m := new(bn256.G1).ScalarBaseMult(k).Marshal()
+	return []string{
+		new(big.Int).SetBytes(m[:32]).String(),
+		new(big.Int).SetBytes(m[32:]).String(),
+		"1",
+	}
+}
+
+func g2Strings(k *big.Int) [][]string {
+	m := new(bn256.G2).ScalarBaseMult(k).Marshal()
+	word := func(i int) string {
+		return new(big.Int).SetBytes(m[i*32 : (i+1)*32]).String()
+	}
+	return [][]string{{word(1), word(0)}, {word(3), word(2)}, {"1", "0"}}
+}
+
+// setup returns verification key with known trapdoor and a proof for the
+// public signals built with the trapdoor.
+func setup(t *testing.T, public []*big.Int) (*VerificationKey, *prover.Proof) {
+	mul := func(a, b *big.Int) *big.Int {
+		return new(big.Int).Mod(new(big.Int).Mul(a, b), bn256.Order)
+	}
+
+	alpha, beta, gamma, delta := randScalar(t), randScalar(t), randScalar(t), randScalar(t)
+	vk := &VerificationKey{
+		Protocol: "groth16",
+		Curve:    "bn128",
+		NPublic:  len(public),
+		Alpha:    g1Strings(alpha),
+		Beta:     g2Strings(beta),
+		Gamma:    g2Strings(gamma),
+		Delta:    g2Strings(delta),
+	}
+
+	x := new(big.Int)
+	for i := 0; i <= len(public); i++ {
+		ic := randScalar(t)
+		vk.IC = append(vk.IC, g1Strings(ic))
+		if i > 0 {
+			ic = mul(ic, public[i-1])
+		}
+		x.Add(x, ic)
+	}
+
+	// a*b = alpha*beta + x*gamma + c*delta
+	a, b := randScalar(t), randScalar(t)
+	c := new(big.Int).Sub(mul(a, b), mul(alpha, beta))
+	c.Sub(c, mul(x, gamma))
+	c = mul(c, new(big.Int).ModInverse(delta, bn256.Order))
+
+	return vk, &prover.Proof{
+		A:        g1Strings(a),
+		B:        g2Strings(b),
+		C:        g1Strings(c),
+		Protocol: "groth16",
+		Curve:    "bn128",
+	}
+}
+
+func Test_Verify(t *testing.T) {
+	public := []*big.Int{big.NewInt(1), big.NewInt(12345), big.NewInt(0)}
+	vk, proof := setup(t, public)
+
+	require.NoError(t, Verify(vk, proof, public))
+
+	tampered := []*big.Int{big.NewInt(1), big.NewInt(12346), big.NewInt(0)}
+	require.ErrorIs(t, Verify(vk, proof, tampered), ErrInvalidProof)
+
+	require.EqualError(t, Verify(vk, proof, public[:2]),
+		"expected 3 public signals, got 2")
+
+	outOfField := []*big.Int{big.NewInt(1), bn256.Order, big.NewInt(0)}
+	require.EqualError(t, Verify(vk, proof, outOfField),
+		"public signal 1 is not in the field")
+}
+
+func Test_VerifyOutputs(t *testing.T) {
+	public := []*big.Int{big.NewInt(10), big.NewInt(20), big.NewInt(30)}
+	vk, proof := setup(t, public)
+
+	type outputs struct {
+		UserID string `json:"userID"`
+	}
+	type inputs struct {
+		Challenge string `json:"challenge"`
+		GistRoot  string `json:"gistRoot"`
+	}
+
+	err := VerifyOutputs(vk, proof, "authV3", outputs{"10"}, inputs{"20", "30"})
+	require.NoError(t, err)
+
+	err = VerifyOutputs(vk, proof, "authV3", outputs{"11"}, inputs{"20", "30"})
+	require.ErrorIs(t, err, ErrInvalidProof)
+}
+
+func Test_VerifyFakeProof(t *testing.T) {
+	public := []*big.Int{big.NewInt(1)}
+	vk, _ := setup(t, public)
+
+	proof, _, err := prover.Fake{}.Prove(context.Background(), "", []byte(`{}`))
+	require.NoError(t, err)
+	require.ErrorIs(t, Verify(vk, proof, public), ErrInvalidProof)
+}
+
+// Test_VerifySnarkjsFiles verifies proof read from snarkjs verification_key.json,
+// proof.json and public.json. Gamma of the key is G2 generator as in keys of
+// snarkjs setup, it pins the order of G2 coordinates: snarkjs puts real part
+// first, Solidity verifier expects imaginary part first.
+func Test_VerifySnarkjsFiles(t *testing.T) {
+	dir := filepath.Join("testdata", "multiplier")
+	vk, err := LoadVerificationKey(filepath.Join(dir, "verification_key.json"))
+	require.NoError(t, err)
+	proof, err := LoadProof(filepath.Join(dir, "proof.json"))
+	require.NoError(t, err)
+	public, err := LoadPublic(filepath.Join(dir, "public.json"))
+	require.NoError(t, err)
+
+	gamma, err := g2Point(vk.Gamma)
+	require.NoError(t, err)
+	require.Equal(t, new(bn256.G2).ScalarBaseMult(big.NewInt(1)).Marshal(), gamma.Marshal())
+
+	require.NoError(t, Verify(vk, proof, public))
+	require.ErrorIs(t, Verify(vk, proof, []*big.Int{big.NewInt(34)}), ErrInvalidProof)
+
+	// pi_b in Solidity coordinate order doesn't verify
+	swapped := *proof
+	swapped.B = [][]string{
+		{proof.B[0][1], proof.B[0][0]},
+		{proof.B[1][1], proof.B[1][0]},
+		proof.B[2],
+	}
+	require.Error(t, Verify(vk, &swapped, public))
+}
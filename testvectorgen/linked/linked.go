@@ -0,0 +1,276 @@
+// Package linked builds test vectors of linkedMultiQuery circuits: queries of
+// one claim, linked by linkID to other proofs of the same claim made with
+// the same link nonce, e.g. credentialAtomicQueryV3 proofs.
+package linked
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"test/query"
+	"test/utils"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+)
+
+// Sizes are the numbers of queries of compiled linkedMultiQuery circuits
+var Sizes = []int{3, 5, 10}
+
+// CircuitName returns name of linkedMultiQuery circuit of the size
+func CircuitName(size int) string {
+	if size == 10 {
+		return "linkedMultiQuery"
+	}
+	return "linkedMultiQuery" + strconv.Itoa(size)
+}
+
+// Dir returns testdata directory of the vectors of the circuit size: linked
+// for linkedMultiQuery, linked3 for linkedMultiQuery3, etc.
+func Dir(size int) string {
+	if size == 10 {
+		return "linked"
+	}
+	return "linked" + strconv.Itoa(size)
+}
+
+type Inputs struct {
+	LinkNonce            string             `json:"linkNonce"`
+	IssuerClaim          *core.Claim        `json:"issuerClaim"`
+	ClaimSchema          string             `json:"claimSchema"`
+	ClaimPathMtp         [][]string         `json:"claimPathMtp"`
+	ClaimPathMtpNoAux    []string           `json:"claimPathMtpNoAux"` // 1 if aux node is empty, 0 if non-empty or for inclusion proofs
+	ClaimPathMtpAuxHi    []*merkletree.Hash `json:"claimPathMtpAuxHi"` // 0 for inclusion proof
+	ClaimPathMtpAuxHv    []*merkletree.Hash `json:"claimPathMtpAuxHv"` // 0 for inclusion proof
+	ClaimPathKey         []string           `json:"claimPathKey"`      // hash of path in merklized json-ld document
+	ClaimPathValue       []string           `json:"claimPathValue"`    // value in this path in merklized json-ld document
+	SlotIndex            []int              `json:"slotIndex"`
+	Operator             []int              `json:"operator"`
+	Value                [][]string         `json:"value"`
+	ActualValueArraySize []int              `json:"valueArraySize"`
+}
+
+type Outputs struct {
+	LinkID               string   `json:"linkID"`
+	Merklized            int      `json:"merklized"`
+	OperatorOutput       []string `json:"operatorOutput"`
+	CircuitQueryHash     []string `json:"circuitQueryHash"`
+	ActualValueArraySize []int    `json:"valueArraySize"`
+}
+
+type TestData struct {
+	Desc string  `json:"desc"`
+	In   Inputs  `json:"inputs"`
+	Out  Outputs `json:"expOut"`
+}
+
+// Query is a query of one field of the claim: JSON-LD path of merklized
+// claim or slot of non-merklized claim. Path of EXISTS false query is not in
+// the document, it's proved by non-inclusion proof.
+type Query struct {
+	Operator int
+	Values   []*big.Int
+	// Path of the field of merklized claim
+	Path merklize.Path
+	// SlotIndex of the field of non-merklized claim
+	SlotIndex int
+}
+
+// Claim is the queried claim, Merklizer is nil for non-merklized claim
+type Claim struct {
+	Claim     *core.Claim
+	Merklizer *merklize.Merklizer
+}
+
+// Generate returns test vector of linkedMultiQuery circuit of the size with
+// the queries of the claim, unused queries are NOOP. LinkID is the same as
+// of credentialAtomicQueryV3 proofs of the claim with the link nonce.
+func Generate(t testing.TB, desc string, size int, claim Claim, linkNonce string, queries []Query) TestData {
+	if len(queries) > size {
+		t.Fatalf("%d queries, circuit supports %d", len(queries), size)
+	}
+
+	s := Inputs{
+		LinkNonce:            linkNonce,
+		IssuerClaim:          claim.Claim,
+		ClaimSchema:          claim.Claim.GetSchemaHash().BigInt().String(),
+		ClaimPathMtp:         make([][]string, size),
+		ClaimPathMtpNoAux:    make([]string, size),
+		ClaimPathMtpAuxHi:    make([]*merkletree.Hash, size),
+		ClaimPathMtpAuxHv:    make([]*merkletree.Hash, size),
+		ClaimPathKey:         make([]string, size),
+		ClaimPathValue:       make([]string, size),
+		SlotIndex:            make([]int, size),
+		Operator:             make([]int, size),
+		Value:                make([][]string, size),
+		ActualValueArraySize: make([]int, size),
+	}
+	operatorOutput := make([]string, size)
+
+	merklized := 0
+	if claim.Merklizer != nil {
+		merklized = 1
+	}
+
+	for i := 0; i < size; i++ {
+		// unused queries are NOOP of empty path
+		s.ClaimPathMtp[i] = utils.PrepareSiblingsStr([]*merkletree.Hash{}, utils.ClaimLevels)
+		s.ClaimPathMtpNoAux[i] = "0"
+		s.ClaimPathMtpAuxHi[i] = &merkletree.HashZero
+		s.ClaimPathMtpAuxHv[i] = &merkletree.HashZero
+		s.ClaimPathKey[i] = "0"
+		s.ClaimPathValue[i] = "0"
+		s.Value[i] = prepareValues(t, nil)
+		operatorOutput[i] = "0"
+		if i >= len(queries) {
+			continue
+		}
+
+		query := queries[i]
+		s.Operator[i] = query.Operator
+		s.ActualValueArraySize[i] = len(query.Values)
+		s.Value[i] = prepareValues(t, query.Values)
+
+		var fieldValue *big.Int
+		if claim.Merklizer != nil {
+			fieldValue = claimPathProof(t, claim.Merklizer, query.Path, &s, i)
+		} else {
+			s.SlotIndex[i] = query.SlotIndex
+			fieldValue = claim.Claim.RawSlotsAsInts()[query.SlotIndex]
+		}
+		// selective disclosure outputs the value of the field
+		if query.Operator == utils.SD {
+			operatorOutput[i] = fieldValue.String()
+		}
+	}
+
+	linkID, err := utils.CalculateLinkID(linkNonce, claim.Claim)
+	if err != nil {
+		t.Fatalf("failed calculate linkID: %v", err)
+	}
+
+	return TestData{
+		Desc: desc,
+		In:   s,
+		Out: Outputs{
+			Merklized:            merklized,
+			LinkID:               linkID,
+			OperatorOutput:       operatorOutput,
+			CircuitQueryHash:     fillCircuitQueryHash(t, s, merklized),
+			ActualValueArraySize: s.ActualValueArraySize,
+		},
+	}
+}
+
+// claimPathProof sets the proof of the path of i-th query and returns the
+// value of the path, 0 if the path is not in the document
+func claimPathProof(t testing.TB, mz *merklize.Merklizer, path merklize.Path, s *Inputs, i int) *big.Int {
+	jsonP, value, err := mz.Proof(context.Background(), path)
+	if err != nil {
+		t.Fatalf("failed generate claim path proof: %v", err)
+	}
+	valueKey := big.NewInt(0)
+	if value != nil {
+		valueKey, err = value.MtEntry()
+		if err != nil {
+			t.Fatalf("failed get value entry: %v", err)
+		}
+	}
+	pathKey, err := path.MtEntry()
+	if err != nil {
+		t.Fatalf("failed get path entry: %v", err)
+	}
+
+	var aux utils.NodeAuxValue
+	s.ClaimPathMtp[i], aux = utils.PrepareProof(jsonP, utils.ClaimLevels)
+	s.ClaimPathMtpNoAux[i] = aux.NoAux
+	s.ClaimPathMtpAuxHi[i] = hashFromString(t, aux.Key)
+	s.ClaimPathMtpAuxHv[i] = hashFromString(t, aux.Value)
+	s.ClaimPathKey[i] = pathKey.String()
+	s.ClaimPathValue[i] = valueKey.String()
+	return valueKey
+}
+
+// Save saves the test vector and snarkjs files of linkedMultiQuery circuit
+// of the vector size
+func Save(t *testing.T, fileName string, data TestData) {
+	t.Helper()
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("failed marshal test data: %v", err)
+	}
+
+	utils.SaveTestVector(t, fileName, string(jsonData))
+	utils.SaveSnarkjsFiles(t, CircuitName(len(data.In.Operator)), fileName, string(jsonData))
+}
+
+func hashFromString(t testing.TB, s string) *merkletree.Hash {
+	h, err := merkletree.NewHashFromString(s)
+	if err != nil {
+		t.Fatalf("failed convert %s to hash: %v", s, err)
+	}
+	return h
+}
+
+func prepareValues(t testing.TB, values []*big.Int) []string {
+	arr, err := PrepareCircuitArrayValues(values, 64)
+	if err != nil {
+		t.Fatalf("failed prepare query values: %v", err)
+	}
+	return bigIntArrayToStringArray(arr)
+}
+
+// fillCircuitQueryHash returns circuitQueryHash outputs of the queries. The
+// hash is the one of V3 circuits without revocation check, verifier and
+// nullifier session.
+func fillCircuitQueryHash(t testing.TB, s Inputs, merklized int) []string {
+	arr := make([]string, len(s.Operator))
+	for i := range arr {
+		q := query.Query{
+			Inputs: query.Inputs{
+				ClaimSchema:    s.ClaimSchema,
+				ClaimPathKey:   s.ClaimPathKey[i],
+				Operator:       s.Operator[i],
+				SlotIndex:      s.SlotIndex[i],
+				Value:          s.Value[i],
+				ValueArraySize: s.ActualValueArraySize[i],
+			},
+			Merklized: merklized,
+		}
+		queryHash, err := q.CircuitQueryHash(big.NewInt(0), big.NewInt(0))
+		if err != nil {
+			t.Fatalf("failed calculate query hash: %v", err)
+		}
+		arr[i] = queryHash.String()
+	}
+	return arr
+}
+
+// PrepareCircuitArrayValues pads the values with zeros to the size
+func PrepareCircuitArrayValues(arr []*big.Int, size int) ([]*big.Int, error) {
+	if len(arr) > size {
+		return nil, errors.New("too many values")
+	}
+
+	res := make([]*big.Int, 0, size)
+	res = append(res, arr...)
+	for i := len(arr); i < size; i++ {
+		res = append(res, new(big.Int))
+	}
+
+	return res, nil
+}
+
+func bigIntArrayToStringArray(array []*big.Int) []string {
+	res := make([]string, 0, len(array))
+	for i := range array {
+		res = append(res, array[i].String())
+	}
+	return res
+}
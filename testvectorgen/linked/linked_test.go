@@ -0,0 +1,104 @@
+package linked
+
+import (
+	"math/big"
+	"testing"
+
+	"test/pubsignals"
+	"test/snarkjs"
+	"test/utils"
+
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/stretchr/testify/require"
+)
+
+const userPK = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69e"
+
+func Test_CircuitName(t *testing.T) {
+	require.Equal(t, "linkedMultiQuery3", CircuitName(3))
+	require.Equal(t, "linkedMultiQuery5", CircuitName(5))
+	require.Equal(t, "linkedMultiQuery", CircuitName(10))
+	require.Equal(t, "linked3", Dir(3))
+	require.Equal(t, "linked", Dir(10))
+
+	// Save passes the circuit name to SaveSnarkjsFiles, it needs the layout
+	// declared by the circuit
+	for _, size := range Sizes {
+		c, err := snarkjs.FindCircuit(CircuitName(size))
+		require.NoError(t, err)
+		l, err := pubsignals.Get(CircuitName(size))
+		require.NoError(t, err)
+		require.Equal(t, c.PublicSignals(), l.Names())
+		require.Equal(t, 2+2*size, l.Len())
+	}
+}
+
+func Test_GenerateNonMerklized(t *testing.T) {
+	user := utils.NewIdentity(t, userPK)
+	claim := utils.DefaultUserClaim(t, user.ID, nil, utils.WithSlot(utils.SlotValueA, 7))
+
+	data := Generate(t, "desc", 5, Claim{Claim: claim}, "18", []Query{
+		{Operator: utils.EQ, Values: []*big.Int{big.NewInt(10)}, SlotIndex: utils.SlotIndexA},
+		{Operator: utils.SD, SlotIndex: utils.SlotValueA},
+	})
+
+	linkID, err := utils.CalculateLinkID("18", claim)
+	require.NoError(t, err)
+	require.Equal(t, linkID, data.Out.LinkID)
+	require.Equal(t, 0, data.Out.Merklized)
+	require.Equal(t, []int{utils.SlotIndexA, utils.SlotValueA, 0, 0, 0}, data.In.SlotIndex)
+	require.Equal(t, []int{utils.EQ, utils.SD, 0, 0, 0}, data.In.Operator)
+	// selective disclosure outputs the value of the slot
+	require.Equal(t, []string{"0", "7", "0", "0", "0"}, data.Out.OperatorOutput)
+
+	// unused queries have the same hash
+	require.Len(t, data.Out.CircuitQueryHash, 5)
+	require.NotEqual(t, data.Out.CircuitQueryHash[0], data.Out.CircuitQueryHash[1])
+	require.NotEqual(t, data.Out.CircuitQueryHash[1], data.Out.CircuitQueryHash[2])
+	require.Equal(t, data.Out.CircuitQueryHash[2], data.Out.CircuitQueryHash[4])
+
+	// the query hash doesn't depend on the circuit size
+	data10 := Generate(t, "desc", 10, Claim{Claim: claim}, "18", []Query{
+		{Operator: utils.EQ, Values: []*big.Int{big.NewInt(10)}, SlotIndex: utils.SlotIndexA},
+	})
+	require.Len(t, data10.In.Operator, 10)
+	require.Equal(t, data.Out.CircuitQueryHash[0], data10.Out.CircuitQueryHash[0])
+	require.Equal(t, data.Out.CircuitQueryHash[4], data10.Out.CircuitQueryHash[9])
+}
+
+func Test_GenerateMerklized(t *testing.T) {
+	const credential = `{
+  "@context": {
+    "credentialSubject": {"@id": "https://www.w3.org/2018/credentials#credentialSubject"},
+    "birthday": {"@id": "https://example.com#birthday", "@type": "http://www.w3.org/2001/XMLSchema#integer"},
+    "documentType": {"@id": "https://example.com#documentType", "@type": "http://www.w3.org/2001/XMLSchema#integer"}
+  },
+  "credentialSubject": {"birthday": 19960424, "documentType": 2}
+}`
+	user := utils.NewIdentity(t, userPK)
+	mz, claim := utils.NewMerklizedClaim(t, credential, utils.WithSubject(user.ID),
+		utils.WithSchemaHash(utils.DefaultUserClaim(t, user.ID, nil).GetSchemaHash()))
+	newPath := func(field string) merklize.Path {
+		path, err := merklize.NewPath("https://www.w3.org/2018/credentials#credentialSubject",
+			"https://example.com#"+field)
+		require.NoError(t, err)
+		return path
+	}
+
+	data := Generate(t, "desc", 3, Claim{Claim: claim, Merklizer: mz}, "1", []Query{
+		{Operator: utils.LT, Values: []*big.Int{big.NewInt(20020101)}, Path: newPath("birthday")},
+		{Operator: utils.SD, Path: newPath("documentType")},
+		{Operator: utils.EXISTS, Values: []*big.Int{big.NewInt(0)}, Path: newPath("missing")},
+	})
+	require.Equal(t, 1, data.Out.Merklized)
+	require.Equal(t, []int{0, 0, 0}, data.In.SlotIndex)
+	require.Equal(t, []string{"19960424", "2", "0"}, data.In.ClaimPathValue)
+	require.Equal(t, []string{"0", "2", "0"}, data.Out.OperatorOutput)
+
+	// each query has its own path and proof
+	require.NotEqual(t, data.In.ClaimPathKey[0], data.In.ClaimPathKey[1])
+	require.NotEqual(t, data.In.ClaimPathMtp[0], data.In.ClaimPathMtp[1])
+	// missing path is proved by non-inclusion proof with aux node
+	require.Equal(t, "0", data.In.ClaimPathMtpNoAux[2])
+	require.NotEqual(t, "0", data.In.ClaimPathMtpAuxHi[2].BigInt().String())
+}
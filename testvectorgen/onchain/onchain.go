@@ -0,0 +1,350 @@
+// Package onchain simulates on-chain issuers: identities based on Ethereum
+// address which publish their states to the State contract and serve
+// revocation status of issued claims from the identity contract, as used
+// by Iden3OnchainSparseMerkleTreeProof2023 credential status.
+package onchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"test/utils"
+	"test/vc"
+
+	"github.com/ethereum/go-ethereum/common"
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+)
+
+// ChainID is the chain of identities created by utils.NewEthereumBasedIdentity
+const ChainID = 80001
+
+// Registry simulates State contract: history of identity states and GIST
+// of the latest states
+type Registry struct {
+	gist   *merkletree.MerkleTree
+	states map[core.ID][]*big.Int
+}
+
+// NewRegistry returns registry without identities
+func NewRegistry(t testing.TB) *Registry {
+	gist, err := merkletree.NewMerkleTree(context.Background(), memory.NewMemoryStorage(), utils.GistLevels)
+	if err != nil {
+		t.Fatalf("Error creating GIST: %v", err)
+	}
+	return &Registry{gist: gist, states: map[core.ID][]*big.Int{}}
+}
+
+// TransitState publishes new state of the identity. Old state must be the
+// latest state of the identity, or for the first transition the genesis
+// state of the identity. Identities based on Ethereum address have no
+// genesis state, any old state is accepted.
+func (r *Registry) TransitState(id core.ID, oldState, newState *big.Int) error {
+	ctx := context.Background()
+	if oldState.Cmp(newState) == 0 {
+		return errors.New("new state is the same as old state")
+	}
+
+	states, ok := r.states[id]
+	if ok {
+		latest := states[len(states)-1]
+		if latest.Cmp(oldState) != 0 {
+			return fmt.Errorf("old state %s is not the latest state %s", oldState, latest)
+		}
+	} else {
+		genesis, err := core.CheckGenesisStateID(id.BigInt(), oldState)
+		if err != nil {
+			return err
+		}
+		if !genesis && !isEthereumBased(id) {
+			return fmt.Errorf("old state %s is not the genesis state of %s", oldState, id.String())
+		}
+		states = []*big.Int{oldState}
+	}
+
+	idHash, err := poseidon.Hash([]*big.Int{id.BigInt()})
+	if err != nil {
+		return err
+	}
+	if ok {
+		_, err = r.gist.Update(ctx, idHash, newState)
+	} else {
+		err = r.gist.Add(ctx, idHash, newState)
+	}
+	if err != nil {
+		return err
+	}
+	r.states[id] = append(states, newState)
+	return nil
+}
+
+// isEthereumBased returns true if genesis of the ID is Ethereum address
+func isEthereumBased(id core.ID) bool {
+	addr, err := core.EthAddressFromID(id)
+	if err != nil {
+		return false
+	}
+	return core.NewID(id.Type(), core.GenesisFromEthAddress(addr)) == id
+}
+
+// State returns the latest state of the identity
+func (r *Registry) State(id core.ID) (*big.Int, bool) {
+	states, ok := r.states[id]
+	if !ok {
+		return nil, false
+	}
+	return states[len(states)-1], true
+}
+
+// StateExists returns true if the state was published by the identity
+func (r *Registry) StateExists(id core.ID, state *big.Int) bool {
+	for _, s := range r.states[id] {
+		if s.Cmp(state) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GistRoot returns the root of GIST
+func (r *Registry) GistRoot() *merkletree.Hash {
+	return r.gist.Root()
+}
+
+// GistProof returns GIST proof of the identity
+func (r *Registry) GistProof(t testing.TB, id core.ID) ([]string, utils.NodeAuxValue) {
+	idHash, err := poseidon.Hash([]*big.Int{id.BigInt()})
+	if err != nil {
+		t.Fatalf("can't hash id %v", err)
+	}
+	proof, _, err := r.gist.GenerateProof(context.Background(), idHash, nil)
+	if err != nil {
+		t.Fatalf("can't generate GIST proof %v", err)
+	}
+	return utils.PrepareProof(proof, utils.GistLevels)
+}
+
+// treeState is published state with roots of the trees
+type treeState struct {
+	state, claimsTreeRoot, revTreeRoot, rootsTreeRoot *merkletree.Hash
+}
+
+// Issuer is identity contract based on Ethereum address. Claims and
+// revocations are added to the trees of the identity and published by
+// TransitState.
+type Issuer struct {
+	*utils.IdentityTest
+	Address  common.Address
+	Registry *Registry
+	// genesis is the state of empty trees, the old state of the first
+	// transition
+	genesis *big.Int
+	// published are published states by state hash
+	published map[merkletree.Hash]treeState
+	latest    *merkletree.Hash
+}
+
+// NewIssuer returns issuer of the Ethereum address with empty trees, its
+// state is not published
+func NewIssuer(t testing.TB, ethAddr string, registry *Registry) *Issuer {
+	it := utils.NewEthereumBasedIdentity(t, ethAddr)
+	return &Issuer{
+		IdentityTest: it,
+		Address:      common.HexToAddress(ethAddr),
+		Registry:     registry,
+		genesis:      it.State(t),
+		published:    map[merkletree.Hash]treeState{},
+	}
+}
+
+// Issue adds claims to the claims tree and publishes new state
+func (i *Issuer) Issue(t testing.TB, claims ...*core.Claim) {
+	for _, claim := range claims {
+		hi, hv, err := claim.HiHv()
+		if err != nil {
+			t.Fatalf("Error calculating hi and hv: %v", err)
+		}
+		err = i.Clt.Add(context.Background(), hi, hv)
+		if err != nil {
+			t.Fatalf("Error adding claim to claimsMT: %v", err)
+		}
+	}
+	i.TransitState(t)
+}
+
+// Revoke adds revocation nonces to the revocation tree and publishes new
+// state
+func (i *Issuer) Revoke(t testing.TB, nonces ...uint64) {
+	for _, nonce := range nonces {
+		err := i.Ret.Add(context.Background(), new(big.Int).SetUint64(nonce), big.NewInt(0))
+		if err != nil {
+			t.Fatalf("Error adding nonce to Revocation merkle tree: %v", err)
+		}
+	}
+	i.TransitState(t)
+}
+
+// TransitState adds the claims tree root to the roots tree, like identity
+// contract does, and publishes the state of the trees
+func (i *Issuer) TransitState(t testing.TB) {
+	ctx := context.Background()
+
+	root := i.Clt.Root().BigInt()
+	_, _, _, err := i.Rot.Get(ctx, root)
+	if errors.Is(err, merkletree.ErrKeyNotFound) {
+		err = i.Rot.Add(ctx, root, big.NewInt(0))
+	}
+	if err != nil {
+		t.Fatalf("Error adding claims tree root to Roots merkle tree: %v", err)
+	}
+	oldState := i.genesis
+	if i.latest != nil {
+		oldState = i.latest.BigInt()
+	}
+
+	newState := i.State(t)
+	err = i.Registry.TransitState(i.ID, oldState, newState)
+	if err != nil {
+		t.Fatalf("Error transiting state: %v", err)
+	}
+
+	state, err := merkletree.NewHashFromBigInt(newState)
+	if err != nil {
+		t.Fatalf("failed convert state to hash: %v", err)
+	}
+	i.published[*state] = treeState{
+		state:          state,
+		claimsTreeRoot: i.Clt.Root(),
+		revTreeRoot:    i.Ret.Root(),
+		rootsTreeRoot:  i.Rot.Root(),
+	}
+	i.latest = state
+}
+
+// LatestState returns the latest published state
+func (i *Issuer) LatestState(t testing.TB) *big.Int {
+	if i.latest == nil {
+		t.Fatalf("issuer %s has no published state", i.ID.String())
+	}
+	return i.latest.BigInt()
+}
+
+func (i *Issuer) publishedState(t testing.TB, state *big.Int) treeState {
+	h, err := merkletree.NewHashFromBigInt(state)
+	if err != nil {
+		t.Fatalf("failed convert state to hash: %v", err)
+	}
+	s, ok := i.published[*h]
+	if !ok {
+		t.Fatalf("state %s is not published by issuer %s", state, i.ID.String())
+	}
+	return s
+}
+
+// CredentialStatus returns Iden3OnchainSparseMerkleTreeProof2023 status of
+// the claim at the state
+func (i *Issuer) CredentialStatus(t testing.TB, claim *core.Claim, state *big.Int) verifiable.CredentialStatus {
+	did, err := core.ParseDIDFromID(i.ID)
+	if err != nil {
+		t.Fatalf("Error creating did from id: %v", err)
+	}
+	s := i.publishedState(t, state)
+	revNonce := claim.GetRevocationNonce()
+	return verifiable.CredentialStatus{
+		ID: fmt.Sprintf("%s/credentialStatus?revocationNonce=%d&contractAddress=%d:%s&state=%s",
+			did.String(), revNonce, ChainID, i.Address.Hex(), s.state.Hex()),
+		Type:            verifiable.Iden3OnchainSparseMerkleTreeProof2023,
+		RevocationNonce: revNonce,
+	}
+}
+
+// RevocationStatus returns revocation status of the nonce at the published
+// state, as getRevocationStatusByIdAndState of identity contract does
+func (i *Issuer) RevocationStatus(t testing.TB, state *big.Int, revNonce uint64) verifiable.RevocationStatus {
+	s := i.publishedState(t, state)
+	proof, _, err := i.Ret.GenerateProof(context.Background(), new(big.Int).SetUint64(revNonce), s.revTreeRoot)
+	if err != nil {
+		t.Fatalf("can't generate proof %v", err)
+	}
+	return verifiable.RevocationStatus{
+		Issuer: verifiable.TreeState{
+			State:              hexStr(s.state),
+			ClaimsTreeRoot:     hexStr(s.claimsTreeRoot),
+			RevocationTreeRoot: hexStr(s.revTreeRoot),
+			RootOfRoots:        hexStr(s.rootsTreeRoot),
+		},
+		MTP: *proof,
+	}
+}
+
+// Credential returns credential of the claim with Iden3SparseMerkleTreeProof
+// at the latest published state and on-chain credential status
+func (i *Issuer) Credential(t testing.TB, claim *core.Claim) *verifiable.W3CCredential {
+	did, err := core.ParseDIDFromID(i.ID)
+	if err != nil {
+		t.Fatalf("Error creating did from id: %v", err)
+	}
+	s := i.publishedState(t, i.LatestState(t))
+
+	hi, err := claim.HIndex()
+	if err != nil {
+		t.Fatalf("can't get claim hash index %v", err)
+	}
+	mtp, _, err := i.Clt.GenerateProof(context.Background(), hi, s.claimsTreeRoot)
+	if err != nil {
+		t.Fatalf("can't generate proof %v", err)
+	}
+	if !mtp.Existence {
+		t.Fatalf("claim is not issued at the state %s", s.state.BigInt())
+	}
+	claimHex, err := claim.Hex()
+	if err != nil {
+		t.Fatalf("can't encode claim %v", err)
+	}
+
+	return &verifiable.W3CCredential{
+		Context:           []string{verifiable.JSONLDSchemaW3CCredential2018},
+		Type:              []string{verifiable.TypeW3CVerifiableCredential},
+		Issuer:            did.String(),
+		CredentialSubject: map[string]any{},
+		CredentialStatus:  i.CredentialStatus(t, claim, s.state.BigInt()),
+		Proof: verifiable.CredentialProofs{&verifiable.Iden3SparseMerkleTreeProof{
+			Type: verifiable.Iden3SparseMerkleTreeProofType,
+			IssuerData: verifiable.IssuerData{
+				ID: did.String(),
+				State: verifiable.State{
+					Value:              hexStr(s.state),
+					ClaimsTreeRoot:     hexStr(s.claimsTreeRoot),
+					RevocationTreeRoot: hexStr(s.revTreeRoot),
+					RootOfRoots:        hexStr(s.rootsTreeRoot),
+				},
+			},
+			CoreClaim: claimHex,
+			MTP:       mtp,
+		}},
+	}
+}
+
+// IssuerInputs returns MTP issuer inputs of credential atomic query V3
+// circuits for the credential of the claim, with non-revocation proof at
+// the latest published state
+func (i *Issuer) IssuerInputs(t testing.TB, claim *core.Claim) *vc.IssuerInputs {
+	cred := i.Credential(t, claim)
+	status := i.RevocationStatus(t, i.LatestState(t), claim.GetRevocationNonce())
+	in, err := vc.Import(cred, verifiable.Iden3SparseMerkleTreeProofType, vc.Status{Claim: status})
+	if err != nil {
+		t.Fatalf("can't import credential %v", err)
+	}
+	return in
+}
+
+func hexStr(h *merkletree.Hash) *string {
+	s := h.Hex()
+	return &s
+}
@@ -0,0 +1,104 @@
+package onchain
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"test/utils"
+
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userPK     = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69e"
+	ethAddress = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+)
+
+func Test_RegistryTransitState(t *testing.T) {
+	r := NewRegistry(t)
+	user := utils.NewIdentity(t, userPK)
+	genesis := user.State(t)
+
+	// first transition of BJJ identity is from its genesis state
+	err := r.TransitState(user.ID, big.NewInt(1), big.NewInt(2))
+	require.ErrorContains(t, err, "genesis")
+	err = r.TransitState(user.ID, genesis, big.NewInt(2))
+	require.NoError(t, err)
+
+	// next transitions are from the latest state
+	err = r.TransitState(user.ID, genesis, big.NewInt(3))
+	require.ErrorContains(t, err, "latest")
+	err = r.TransitState(user.ID, big.NewInt(2), big.NewInt(2))
+	require.Error(t, err)
+	err = r.TransitState(user.ID, big.NewInt(2), big.NewInt(3))
+	require.NoError(t, err)
+
+	state, ok := r.State(user.ID)
+	require.True(t, ok)
+	require.Equal(t, big.NewInt(3), state)
+	require.True(t, r.StateExists(user.ID, genesis))
+	require.True(t, r.StateExists(user.ID, big.NewInt(2)))
+	require.False(t, r.StateExists(user.ID, big.NewInt(4)))
+
+	// GIST has the latest state
+	_, aux := r.GistProof(t, user.ID)
+	require.Equal(t, "0", aux.NoAux)
+	require.Equal(t, "0", aux.Key)
+}
+
+func Test_IssuerInputs(t *testing.T) {
+	r := NewRegistry(t)
+	issuer := NewIssuer(t, ethAddress, r)
+	user := utils.NewIdentity(t, userPK)
+	claim := utils.DefaultUserClaim(t, user.ID, nil)
+	other := utils.DefaultUserClaim(t, user.ID, big.NewInt(11), utils.WithRevocationNonce(2))
+
+	issuer.Issue(t, claim, other)
+	issued := issuer.LatestState(t)
+	state, ok := r.State(issuer.ID)
+	require.True(t, ok)
+	require.Equal(t, issuer.State(t), state)
+	require.Equal(t, issued, state)
+
+	// claims tree root is in the roots tree of published state
+	_, _, _, err := issuer.Rot.Get(context.Background(), issuer.Clt.Root().BigInt())
+	require.NoError(t, err)
+
+	in := issuer.IssuerInputs(t, claim)
+	issuerClaimMtp, _ := issuer.ClaimMTP(t, claim)
+	issuerClaimNonRevMtp, aux := issuer.ClaimRevMTP(t, claim)
+	require.Equal(t, "2", in.ProofType)
+	require.Equal(t, issuer.ID.BigInt().String(), in.IssuerID)
+	require.Equal(t, claim, in.IssuerClaim)
+	require.Equal(t, issuerClaimMtp, in.IssuerClaimMtp)
+	require.Equal(t, issued.String(), in.IssuerClaimIdenState)
+	require.Equal(t, issuer.Rot.Root(), in.IssuerClaimRootsTreeRoot)
+	require.Equal(t, issued.String(), in.IssuerClaimNonRevState)
+	require.Equal(t, issuerClaimNonRevMtp, in.IssuerClaimNonRevMtp)
+	require.Equal(t, aux.NoAux, in.IssuerClaimNonRevMtpNoAux)
+
+	cred := issuer.Credential(t, claim)
+	status, ok := cred.CredentialStatus.(verifiable.CredentialStatus)
+	require.True(t, ok)
+	require.Equal(t, verifiable.Iden3OnchainSparseMerkleTreeProof2023, status.Type)
+	require.Equal(t, claim.GetRevocationNonce(), status.RevocationNonce)
+	require.True(t, strings.Contains(status.ID, "contractAddress=80001:"+issuer.Address.Hex()))
+
+	// revocation is published by new state, the claim is still issued at
+	// the old one
+	issuer.Revoke(t, other.GetRevocationNonce())
+	require.True(t, r.StateExists(issuer.ID, issued))
+	require.NotEqual(t, issued, issuer.LatestState(t))
+
+	revoked := issuer.RevocationStatus(t, issuer.LatestState(t), other.GetRevocationNonce())
+	require.True(t, revoked.MTP.Existence)
+	notRevoked := issuer.RevocationStatus(t, issued, other.GetRevocationNonce())
+	require.False(t, notRevoked.MTP.Existence)
+
+	in = issuer.IssuerInputs(t, claim)
+	require.Equal(t, issuer.LatestState(t).String(), in.IssuerClaimIdenState)
+	require.Equal(t, issuer.Ret.Root(), in.IssuerClaimNonRevRevTreeRoot)
+}
@@ -0,0 +1,93 @@
+package prover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Exec generates proofs with locally installed snarkjs and, optionally,
+// rapidsnark binaries. Witness is always calculated with snarkjs from the
+// circuit wasm.
+type Exec struct {
+	// Snarkjs is a snarkjs binary, "snarkjs" from PATH if empty
+	Snarkjs string
+	// Rapidsnark is a rapidsnark prover binary. If empty proof is generated
+	// with `snarkjs groth16 prove`.
+	Rapidsnark string
+	// WASM is a witness calculator of the circuit. If empty circuit.wasm
+	// next to the zkey is used, as it is laid out by compile-circuit.sh.
+	WASM string
+}
+
+// Prove calculates witness for the inputs and generates proof with the zkey
+func (e Exec) Prove(ctx context.Context, zkey string, inputs []byte) (*Proof, []string, error) {
+	dir, err := os.MkdirTemp("", "prover")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		inputFile   = filepath.Join(dir, "input.json")
+		witnessFile = filepath.Join(dir, "witness.wtns")
+		proofFile   = filepath.Join(dir, "proof.json")
+		publicFile  = filepath.Join(dir, "public.json")
+	)
+
+	if err = os.WriteFile(inputFile, inputs, 0644); err != nil {
+		return nil, nil, err
+	}
+
+	snarkjs := e.Snarkjs
+	if snarkjs == "" {
+		snarkjs = "snarkjs"
+	}
+	wasm := e.WASM
+	if wasm == "" {
+		wasm = filepath.Join(filepath.Dir(zkey), "circuit.wasm")
+	}
+
+	err = run(ctx, snarkjs, "wtns", "calculate", wasm, inputFile, witnessFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e.Rapidsnark != "" {
+		err = run(ctx, e.Rapidsnark, zkey, witnessFile, proofFile, publicFile)
+	} else {
+		err = run(ctx, snarkjs, "groth16", "prove", zkey, witnessFile, proofFile, publicFile)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var proof Proof
+	if err = readJSON(proofFile, &proof); err != nil {
+		return nil, nil, err
+	}
+	var public []string
+	if err = readJSON(publicFile, &public); err != nil {
+		return nil, nil, err
+	}
+	return &proof, public, nil
+}
+
+func run(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %v: %w: %s", filepath.Base(name), args, err, out)
+	}
+	return nil
+}
+
+func readJSON(fileName string, v any) error {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,40 @@
+package prover
+
+import (
+	"context"
+	"sync"
+)
+
+// MockCall is a Prove call recorded by Mock
+type MockCall struct {
+	ZKey   string
+	Inputs []byte
+}
+
+// Mock is a prover for unit tests. It returns configured proof, public
+// signals and error and records the calls.
+type Mock struct {
+	Proof  *Proof
+	Public []string
+	Err    error
+
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+// Prove records the call and returns configured results
+func (m *Mock) Prove(_ context.Context, zkey string, inputs []byte) (*Proof, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, MockCall{ZKey: zkey, Inputs: inputs})
+	return m.Proof, m.Public, m.Err
+}
+
+// Calls returns recorded Prove calls
+func (m *Mock) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]MockCall(nil), m.calls...)
+}
@@ -0,0 +1,76 @@
+// Package prover generates Groth16 proofs for circuit inputs of the test
+// vectors.
+package prover
+
+import (
+	"context"
+	"crypto/sha256"
+	"math/big"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// Proof is a Groth16 proof in snarkjs proof.json format
+type Proof struct {
+	A        []string   `json:"pi_a"`
+	B        [][]string `json:"pi_b"`
+	C        []string   `json:"pi_c"`
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+}
+
+// Prover generates proof for the circuit inputs (input.json content) with
+// the zkey of the circuit. Public signals are returned in circuit order.
+type Prover interface {
+	Prove(ctx context.Context, zkey string, inputs []byte) (*Proof, []string, error)
+}
+
+// Fake is a deterministic prover for offline tests. It doesn't run the
+// circuit: proof points are valid curve points derived from the hash of the
+// inputs, so the proof doesn't verify, and no public signals are returned.
+type Fake struct{}
+
+// Prove returns fake proof for the inputs
+func (Fake) Prove(_ context.Context, _ string, inputs []byte) (*Proof, []string, error) {
+	a := new(bn256.G1).ScalarBaseMult(fakeScalar(inputs, 0))
+	b := new(bn256.G2).ScalarBaseMult(fakeScalar(inputs, 1))
+	c := new(bn256.G1).ScalarBaseMult(fakeScalar(inputs, 2))
+
+	return &Proof{
+		A:        g1Strings(a),
+		B:        g2Strings(b),
+		C:        g1Strings(c),
+		Protocol: "groth16",
+		Curve:    "bn128",
+	}, nil, nil
+}
+
+func fakeScalar(inputs []byte, i byte) *big.Int {
+	h := sha256.Sum256(append([]byte{i}, inputs...))
+	k := new(big.Int).SetBytes(h[:])
+	return k.Mod(k, bn256.Order)
+}
+
+// g1Strings returns G1 point in snarkjs projective format [x, y, 1]
+func g1Strings(p *bn256.G1) []string {
+	m := p.Marshal()
+	return []string{
+		new(big.Int).SetBytes(m[:32]).String(),
+		new(big.Int).SetBytes(m[32:64]).String(),
+		"1",
+	}
+}
+
+// g2Strings returns G2 point in snarkjs format [[x0, x1], [y0, y1], [1, 0]].
+// Marshalled point has imaginary part of the coordinates first.
+func g2Strings(p *bn256.G2) [][]string {
+	m := p.Marshal()
+	word := func(i int) string {
+		return new(big.Int).SetBytes(m[i*32 : (i+1)*32]).String()
+	}
+	return [][]string{
+		{word(1), word(0)},
+		{word(3), word(2)},
+		{"1", "0"},
+	}
+}
@@ -0,0 +1,83 @@
+package prover
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Fake(t *testing.T) {
+	p1, public, err := Fake{}.Prove(context.Background(), "", []byte(`{"a":"1"}`))
+	require.NoError(t, err)
+	require.Nil(t, public)
+	require.Len(t, p1.A, 3)
+	require.Len(t, p1.B, 3)
+
+	p2, _, err := Fake{}.Prove(context.Background(), "", []byte(`{"a":"1"}`))
+	require.NoError(t, err)
+	require.Equal(t, p1, p2)
+
+	p3, _, err := Fake{}.Prove(context.Background(), "", []byte(`{"a":"2"}`))
+	require.NoError(t, err)
+	require.NotEqual(t, p1.A, p3.A)
+}
+
+func Test_Mock(t *testing.T) {
+	m := &Mock{Public: []string{"1"}}
+	_, public, err := m.Prove(context.Background(), "circuit.zkey", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"1"}, public)
+	require.Equal(t, []MockCall{{ZKey: "circuit.zkey", Inputs: []byte(`{}`)}}, m.Calls())
+
+	m.Err = errors.New("failed")
+	_, _, err = m.Prove(context.Background(), "circuit.zkey", nil)
+	require.EqualError(t, err, "failed")
+}
+
+// fakeSnarkjs writes shell script emulating snarkjs commands used by Exec
+func fakeSnarkjs(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script is required")
+	}
+
+	script := `#!/bin/sh
+set -e
+case "$1 $2" in
+"wtns calculate")
+	test -f "$4"
+	echo "$3" > "$5"
+	;;
+"groth16 prove")
+	test -f "$4"
+	echo '{"pi_a":["1","2","1"],"pi_b":[["1","2"],["3","4"],["1","0"]],"pi_c":["5","6","1"],"protocol":"groth16","curve":"bn128"}' > "$5"
+	echo '["10","20"]' > "$6"
+	;;
+*)
+	echo "unexpected command $*" >&2
+	exit 1
+	;;
+esac
+`
+	fileName := filepath.Join(t.TempDir(), "snarkjs")
+	require.NoError(t, os.WriteFile(fileName, []byte(script), 0755))
+	return fileName
+}
+
+func Test_Exec(t *testing.T) {
+	e := Exec{Snarkjs: fakeSnarkjs(t)}
+
+	proof, public, err := e.Prove(context.Background(), "build/authV3/circuit_final.zkey", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"10", "20"}, public)
+	require.Equal(t, []string{"5", "6", "1"}, proof.C)
+	require.Equal(t, "groth16", proof.Protocol)
+
+	e.Rapidsnark = filepath.Join(t.TempDir(), "missing")
+	_, _, err = e.Prove(context.Background(), "circuit_final.zkey", []byte(`{}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,310 @@
+// Package pubsignals describes public signals layout of the circuits: names
+// and positions of the signals in the public signals array consumed by
+// snarkjs and on-chain verifiers.
+package pubsignals
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Signal is a public signal of the circuit
+type Signal struct {
+	Name string
+	// Size is a number of elements for array signals, 0 for scalar signals
+	Size int
+}
+
+// Len returns number of field elements the signal takes in public signals
+func (s Signal) Len() int {
+	if s.Size == 0 {
+		return 1
+	}
+	return s.Size
+}
+
+// Layout is an ordered list of the circuit public signals: outputs of the
+// main template followed by public inputs, both in declaration order.
+type Layout struct {
+	Circuit string
+	Signals []Signal
+}
+
+// ErrUnknownCircuit returns when there is no layout registered for the circuit
+var ErrUnknownCircuit = errors.New("unknown circuit")
+
+var layouts = map[string]*Layout{}
+
+func init() {
+	register("authV3", scalars("userID", "challenge", "gistRoot"))
+
+	register("stateTransitionV3",
+		scalars("userID", "oldUserState", "newUserState", "isOldStateGenesis"))
+
+	register("credentialAtomicQueryV3", append(
+		scalars("merklized", "userID", "issuerState", "linkID", "nullifier",
+			"operatorOutput", "proofType", "requestID", "issuerID",
+			"isRevocationChecked", "issuerClaimNonRevState", "timestamp",
+			"claimSchema", "claimPathKey", "slotIndex", "operator"),
+		Signal{Name: "value", Size: 64},
+		Signal{Name: "valueArraySize"},
+		Signal{Name: "verifierID"},
+		Signal{Name: "nullifierSessionID"},
+	))
+
+	register("credentialAtomicQueryV3OnChain",
+		scalars("userID", "circuitQueryHash", "issuerState", "linkID",
+			"nullifier", "operatorOutput", "proofType", "requestID",
+			"challenge", "gistRoot", "issuerID", "issuerClaimNonRevState",
+			"timestamp", "isBJJAuthEnabled"))
+
+	register("credentialAtomicQueryV3Universal",
+		scalars("userID", "circuitQueryHash", "issuerState", "linkID",
+			"nullifier", "operatorOutput", "proofType", "requestID",
+			"issuerID", "issuerClaimNonRevState", "timestamp"))
+
+	// linkedMultiQuery circuit has 10 queries, smaller ones are suffixed
+	// with the number of queries
+	linked := map[string]int{
+		"linkedMultiQuery":  10,
+		"linkedMultiQuery5": 5,
+		"linkedMultiQuery3": 3,
+	}
+	for circuit, n := range linked {
+		register(circuit, []Signal{
+			{Name: "linkID"},
+			{Name: "merklized"},
+			{Name: "operatorOutput", Size: n},
+			{Name: "circuitQueryHash", Size: n},
+		})
+	}
+}
+
+func register(circuit string, signals []Signal) {
+	layouts[circuit] = &Layout{Circuit: circuit, Signals: signals}
+}
+
+func scalars(names ...string) []Signal {
+	signals := make([]Signal, len(names))
+	for i, name := range names {
+		signals[i] = Signal{Name: name}
+	}
+	return signals
+}
+
+// Get returns public signals layout of the circuit
+func Get(circuit string) (*Layout, error) {
+	l, ok := layouts[circuit]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCircuit, circuit)
+	}
+	return l, nil
+}
+
+// Circuits returns names of the circuits with registered layouts
+func Circuits() []string {
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Len returns total number of public signals
+func (l *Layout) Len() int {
+	n := 0
+	for _, s := range l.Signals {
+		n += s.Len()
+	}
+	return n
+}
+
+// Names returns names of the signals in the order of public signals
+func (l *Layout) Names() []string {
+	names := make([]string, len(l.Signals))
+	for i, s := range l.Signals {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Index returns position of the first element of the signal in public signals
+func (l *Layout) Index(name string) (int, bool) {
+	i := 0
+	for _, s := range l.Signals {
+		if s.Name == name {
+			return i, true
+		}
+		i += s.Len()
+	}
+	return 0, false
+}
+
+// ToBigInts returns public signals in circuit order. Sources are structs or
+// maps with signals named by json tags, e.g. test vector Outputs and Inputs.
+// Every signal is taken from the first source that has it.
+func (l *Layout) ToBigInts(sources ...any) ([]*big.Int, error) {
+	values := make([]map[string]json.RawMessage, len(sources))
+	for i, src := range sources {
+		b, err := json.Marshal(src)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, &values[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]*big.Int, 0, l.Len())
+	for _, s := range l.Signals {
+		raw, ok := lookup(values, s.Name)
+		if !ok {
+			return nil, fmt.Errorf("%s: public signal %s is missing", l.Circuit, s.Name)
+		}
+		ints, err := toBigInts(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: public signal %s: %w", l.Circuit, s.Name, err)
+		}
+		if len(ints) != s.Len() {
+			return nil, fmt.Errorf("%s: public signal %s: expected %d values, got %d",
+				l.Circuit, s.Name, s.Len(), len(ints))
+		}
+		res = append(res, ints...)
+	}
+	return res, nil
+}
+
+// FromVector returns public signals of the test vector. Signals are taken
+// from expected outputs of the vector, public inputs not present in outputs
+// are taken from inputs.
+func (l *Layout) FromVector(vector []byte) ([]*big.Int, error) {
+	var tv struct {
+		Inputs map[string]json.RawMessage `json:"inputs"`
+		ExpOut map[string]json.RawMessage `json:"expOut"`
+	}
+	if err := json.Unmarshal(vector, &tv); err != nil {
+		return nil, err
+	}
+	return l.ToBigInts(tv.ExpOut, tv.Inputs)
+}
+
+// FromBigInts sets fields of the targets from public signals. Targets are
+// pointers to structs, fields are matched to signals by json tags and signals
+// without matching field are skipped.
+func (l *Layout) FromBigInts(signals []*big.Int, targets ...any) error {
+	if len(signals) != l.Len() {
+		return fmt.Errorf("%s: expected %d public signals, got %d",
+			l.Circuit, l.Len(), len(signals))
+	}
+
+	offset := 0
+	for _, s := range l.Signals {
+		values := signals[offset : offset+s.Len()]
+		offset += s.Len()
+		for _, target := range targets {
+			if err := setField(target, s, values); err != nil {
+				return fmt.Errorf("%s: public signal %s: %w", l.Circuit, s.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func lookup(values []map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	for _, v := range values {
+		if raw, ok := v[name]; ok {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+func toBigInts(raw json.RawMessage) ([]*big.Int, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var res []*big.Int
+	var walk func(v any) error
+	walk = func(v any) error {
+		switch val := v.(type) {
+		case string:
+			i, ok := new(big.Int).SetString(val, 10)
+			if !ok {
+				return fmt.Errorf("invalid value %q", val)
+			}
+			res = append(res, i)
+		case json.Number:
+			i, ok := new(big.Int).SetString(val.String(), 10)
+			if !ok {
+				return fmt.Errorf("invalid value %s", val)
+			}
+			res = append(res, i)
+		case []any:
+			for _, item := range val {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported value type %T", v)
+		}
+		return nil
+	}
+	return res, walk(v)
+}
+
+// setField sets the field of the target tagged with signal name. Value is
+// unmarshalled from its JSON representation, decimal string is tried first and
+// then number, so fields of string, integer, *big.Int and hash types are
+// supported.
+func setField(target any, s Signal, values []*big.Int) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("target should be a pointer to struct, got %T", target)
+	}
+	v = v.Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		tag := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if tag != s.Name {
+			continue
+		}
+
+		field := v.Field(i).Addr().Interface()
+		var err error
+		for _, raw := range representations(s, values) {
+			if err = json.Unmarshal(raw, field); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func representations(s Signal, values []*big.Int) [][]byte {
+	strs := make([]string, len(values))
+	nums := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = `"` + v.String() + `"`
+		nums[i] = v.String()
+	}
+	if s.Size == 0 {
+		return [][]byte{[]byte(strs[0]), []byte(nums[0])}
+	}
+	return [][]byte{
+		[]byte("[" + strings.Join(strs, ",") + "]"),
+		[]byte("[" + strings.Join(nums, ",") + "]"),
+	}
+}
@@ -0,0 +1,87 @@
+package pubsignals
+
+import (
+	"math/big"
+	"testing"
+
+	"test/snarkjs"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LayoutsMatchCircuits(t *testing.T) {
+	for _, name := range Circuits() {
+		t.Run(name, func(t *testing.T) {
+			c, err := snarkjs.FindCircuit(name)
+			require.NoError(t, err)
+
+			l, err := Get(name)
+			require.NoError(t, err)
+
+			arrays := map[string]bool{}
+			for _, s := range c.Signals {
+				arrays[s.Name] = s.Array
+			}
+
+			var names []string
+			for _, s := range l.Signals {
+				names = append(names, s.Name)
+				require.Equal(t, arrays[s.Name], s.Size > 0, s.Name)
+			}
+			require.Equal(t, c.PublicSignals(), names)
+		})
+	}
+}
+
+func Test_RoundTrip(t *testing.T) {
+	type outputs struct {
+		LinkID           string   `json:"linkID"`
+		Merklized        int      `json:"merklized"`
+		OperatorOutput   []string `json:"operatorOutput"`
+		CircuitQueryHash []string `json:"circuitQueryHash"`
+	}
+
+	l, err := Get("linkedMultiQuery3")
+	require.NoError(t, err)
+	require.Equal(t, 8, l.Len())
+
+	out := outputs{
+		LinkID:           "123",
+		Merklized:        1,
+		OperatorOutput:   []string{"0", "10", "0"},
+		CircuitQueryHash: []string{"1", "2", "3"},
+	}
+	signals, err := l.ToBigInts(out)
+	require.NoError(t, err)
+	require.Equal(t, []*big.Int{
+		big.NewInt(123), big.NewInt(1),
+		big.NewInt(0), big.NewInt(10), big.NewInt(0),
+		big.NewInt(1), big.NewInt(2), big.NewInt(3),
+	}, signals)
+
+	i, ok := l.Index("circuitQueryHash")
+	require.True(t, ok)
+	require.Equal(t, 5, i)
+
+	var got outputs
+	require.NoError(t, l.FromBigInts(signals, &got))
+	require.Equal(t, out, got)
+}
+
+func Test_ToBigIntsFromInputs(t *testing.T) {
+	l, err := Get("authV3")
+	require.NoError(t, err)
+
+	signals, err := l.ToBigInts(
+		map[string]string{"userID": "1"},
+		map[string]any{"challenge": 2, "gistRoot": "3", "userID": "4"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}, signals)
+
+	_, err = l.ToBigInts(map[string]string{"userID": "1"})
+	require.EqualError(t, err, "authV3: public signal challenge is missing")
+
+	_, err = Get("authV2")
+	require.ErrorIs(t, err, ErrUnknownCircuit)
+}
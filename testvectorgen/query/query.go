@@ -0,0 +1,387 @@
+// Package query compiles zero-knowledge proof requests of verifiers, written
+// in iden3comm query language, to query inputs of credential atomic query
+// circuits and the circuit query hash the verifier expects.
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"test/utils"
+
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	schemautils "github.com/iden3/go-schema-processor/v2/utils"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"github.com/piprate/json-gold/ld"
+)
+
+// ValueArraySize is the size of query value array of the circuits
+const ValueArraySize = 64
+
+const credentialSubjectIRI = "https://www.w3.org/2018/credentials#credentialSubject"
+
+// operators maps operators of the query language to circuit operators
+var operators = map[string]int{
+	"$eq":         utils.EQ,
+	"$lt":         utils.LT,
+	"$gt":         utils.GT,
+	"$in":         utils.IN,
+	"$nin":        utils.NIN,
+	"$ne":         utils.NE,
+	"$lte":        utils.LTE,
+	"$gte":        utils.GTE,
+	"$between":    utils.BETWEEN,
+	"$nonbetween": utils.NOT_BETWEEN,
+	"$exists":     utils.EXISTS,
+}
+
+// Request is the query of zero-knowledge proof request, e.g.
+//
+//	{
+//	  "context": "https://example.com/kyc-v4.jsonld",
+//	  "type": "KYCAgeCredential",
+//	  "credentialSubject": {"birthday": {"$lt": 20000101}}
+//	}
+//
+// Request without credential subject is compiled to NOOP query, field
+// without operator, e.g. {"birthday": {}}, to selective disclosure.
+type Request struct {
+	Context                  string         `json:"context"`
+	Type                     string         `json:"type"`
+	CredentialSubject        map[string]any `json:"credentialSubject,omitempty"`
+	SkipClaimRevocationCheck bool           `json:"skipClaimRevocationCheck,omitempty"`
+}
+
+// ParseRequest parses request JSON. Numbers are kept as json.Number, so large
+// integers don't lose precision.
+func ParseRequest(data []byte) (Request, error) {
+	var req Request
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&req)
+	return req, err
+}
+
+// Inputs are query inputs of credential atomic query circuits
+type Inputs struct {
+	ClaimSchema         string   `json:"claimSchema"`
+	ClaimPathKey        string   `json:"claimPathKey"`
+	Operator            int      `json:"operator"`
+	SlotIndex           int      `json:"slotIndex"`
+	Value               []string `json:"value"`
+	ValueArraySize      int      `json:"valueArraySize"`
+	IsRevocationChecked int      `json:"isRevocationChecked"`
+}
+
+// Query is the compiled request
+type Query struct {
+	Inputs
+	// Merklized is 1 if credentials of the type are merklized
+	Merklized int
+	// Field is the queried field of credential subject, empty for NOOP
+	// queries
+	Field string
+	// Path is the path of queried field of merklized credential
+	Path merklize.Path
+	// Datatype is JSON-LD datatype of the queried field
+	Datatype string
+}
+
+// Option configures compiler
+type Option func(*options)
+
+type options struct {
+	documents map[string][]byte
+}
+
+// WithDocument makes JSON-LD document, e.g. the request context, available to
+// compiler without loading it from the url
+func WithDocument(url string, doc []byte) Option {
+	return func(o *options) {
+		o.documents[url] = doc
+	}
+}
+
+// Compile compiles the request to query inputs. Query of merklized credential
+// has the path key of the field, query of non-merklized credential has slot
+// index of the field from iden3_serialization attribute of the type. Values
+// are encoded by the datatype of the field.
+func Compile(req Request, opts ...Option) (*Query, error) {
+	o := options{documents: map[string][]byte{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	documentLoader, err := utils.NewDocumentLoader(o.documents)
+	if err != nil {
+		return nil, err
+	}
+	remoteDoc, err := documentLoader.LoadDocument(req.Context)
+	if err != nil {
+		return nil, fmt.Errorf("failed load context %s: %w", req.Context, err)
+	}
+	ctxBytes, err := json.Marshal(remoteDoc.Document)
+	if err != nil {
+		return nil, err
+	}
+	mzOpts := merklize.Options{DocumentLoader: documentLoader}
+
+	typeID, err := mzOpts.TypeIDFromContext(ctxBytes, req.Type)
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{
+		Inputs: Inputs{
+			ClaimSchema:         schemautils.CreateSchemaHash([]byte(typeID)).BigInt().String(),
+			ClaimPathKey:        "0",
+			Operator:            utils.NOOP,
+			IsRevocationChecked: 1,
+		},
+	}
+	if req.SkipClaimRevocationCheck {
+		q.IsRevocationChecked = 0
+	}
+
+	slots, err := serialization(ctxBytes, req.Type, mzOpts)
+	if err != nil {
+		return nil, err
+	}
+	if slots == nil {
+		q.Merklized = 1
+	}
+
+	field, operator, value, err := fieldQuery(req.CredentialSubject)
+	if err != nil {
+		return nil, err
+	}
+	q.Field = field
+
+	var values []*big.Int
+	if field != "" {
+		q.Datatype, err = mzOpts.TypeFromContext(ctxBytes, req.Type+"."+field)
+		if err != nil {
+			return nil, err
+		}
+
+		if slots != nil {
+			q.SlotIndex, err = slotIndex(slots, field)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			q.Path, err = mzOpts.FieldPathFromContext(ctxBytes, req.Type, field)
+			if err != nil {
+				return nil, err
+			}
+			err = q.Path.Prepend(credentialSubjectIRI)
+			if err != nil {
+				return nil, err
+			}
+			pathKey, err := q.Path.MtEntry()
+			if err != nil {
+				return nil, err
+			}
+			q.ClaimPathKey = pathKey.String()
+		}
+
+		q.Operator = utils.SD
+		if operator != "" {
+			values, err = q.compileOperator(operator, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", field, operator, err)
+			}
+		}
+	}
+
+	q.ValueArraySize = len(values)
+	q.Value = make([]string, len(values))
+	for i, v := range values {
+		q.Value[i] = v.String()
+	}
+	q.Value = utils.PrepareStrArray(q.Value, ValueArraySize)
+
+	return q, nil
+}
+
+// fieldQuery returns the only field of credential subject query with its
+// operator and value. Operator is empty for selective disclosure.
+func fieldQuery(credentialSubject map[string]any) (string, string, any, error) {
+	if len(credentialSubject) == 0 {
+		return "", "", nil, nil
+	}
+	if len(credentialSubject) > 1 {
+		return "", "", nil, fmt.Errorf("multiple fields query is not supported")
+	}
+
+	for field, ops := range credentialSubject {
+		opsM, ok := ops.(map[string]any)
+		if !ok {
+			return "", "", nil, fmt.Errorf("query of field %s is not an object", field)
+		}
+		if len(opsM) > 1 {
+			names := make([]string, 0, len(opsM))
+			for operator := range opsM {
+				names = append(names, operator)
+			}
+			sort.Strings(names)
+			return "", "", nil, fmt.Errorf("multiple operators %s of field %s are not supported",
+				strings.Join(names, ", "), field)
+		}
+		for operator, value := range opsM {
+			return field, operator, value, nil
+		}
+		return field, "", nil, nil
+	}
+	return "", "", nil, nil
+}
+
+// compileOperator sets circuit operator and returns encoded values
+func (q *Query) compileOperator(operator string, value any) ([]*big.Int, error) {
+	op, ok := operators[operator]
+	if !ok {
+		return nil, fmt.Errorf("unknown operator")
+	}
+	q.Operator = op
+
+	switch op {
+	case utils.EXISTS:
+		if q.Merklized == 0 {
+			return nil, fmt.Errorf("operator is not supported by non-merklized credentials")
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value must be boolean")
+		}
+		if b {
+			return []*big.Int{big.NewInt(1)}, nil
+		}
+		return []*big.Int{big.NewInt(0)}, nil
+	case utils.IN, utils.NIN, utils.BETWEEN, utils.NOT_BETWEEN:
+		l, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("value must be an array")
+		}
+		if (op == utils.BETWEEN || op == utils.NOT_BETWEEN) && len(l) != 2 {
+			return nil, fmt.Errorf("value must be an array of 2 elements")
+		}
+		if len(l) == 0 || len(l) > ValueArraySize {
+			return nil, fmt.Errorf("value must have 1 to %d elements", ValueArraySize)
+		}
+		values := make([]*big.Int, len(l))
+		for i, v := range l {
+			e, err := q.encode(v)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = e
+		}
+		return values, nil
+	default:
+		e, err := q.encode(value)
+		if err != nil {
+			return nil, err
+		}
+		return []*big.Int{e}, nil
+	}
+}
+
+// encode encodes JSON value by the datatype of the field
+func (q *Query) encode(v any) (*big.Int, error) {
+	if n, ok := v.(json.Number); ok {
+		if i, ok := new(big.Int).SetString(n.String(), 10); ok {
+			v = i
+		} else {
+			f, err := n.Float64()
+			if err != nil {
+				return nil, err
+			}
+			v = f
+		}
+	}
+	return utils.EncodeValue(q.Datatype, v)
+}
+
+// CircuitQueryHash returns circuit query hash of V3 circuits, the hash the
+// verifier checks the proof against
+func (q *Query) CircuitQueryHash(verifierID, nullifierSessionID *big.Int) (*big.Int, error) {
+	schema, ok := new(big.Int).SetString(q.ClaimSchema, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid claim schema %s", q.ClaimSchema)
+	}
+	pathKey, ok := new(big.Int).SetString(q.ClaimPathKey, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid claim path key %s", q.ClaimPathKey)
+	}
+	valuesHash, err := utils.PoseidonHashValue(utils.FromStringArrayToBigIntArray(q.Value))
+	if err != nil {
+		return nil, err
+	}
+
+	firstPart, err := poseidon.Hash([]*big.Int{
+		schema,
+		big.NewInt(int64(q.SlotIndex)),
+		big.NewInt(int64(q.Operator)),
+		pathKey,
+		big.NewInt(int64(q.Merklized)),
+		valuesHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return poseidon.Hash([]*big.Int{
+		firstPart,
+		big.NewInt(int64(q.ValueArraySize)),
+		big.NewInt(int64(q.IsRevocationChecked)),
+		verifierID,
+		nullifierSessionID,
+		new(big.Int),
+	})
+}
+
+// serialization returns slot paths of non-merklized type from its
+// iden3_serialization attribute, nil for merklized types
+func serialization(ctxBytes []byte, typeName string, mzOpts merklize.Options) (map[string]int, error) {
+	var ctxObj map[string]any
+	err := json.Unmarshal(ctxBytes, &ctxObj)
+	if err != nil {
+		return nil, err
+	}
+	ldCtx, err := ld.NewContext(nil, mzOpts.JSONLDOptions()).Parse(ctxObj["@context"])
+	if err != nil {
+		return nil, err
+	}
+	serAttr, err := verifiable.GetSerializationAttrFromParsedContext(ldCtx, typeName)
+	if err != nil || serAttr == "" {
+		return nil, err
+	}
+	paths, err := verifiable.ParseSerializationAttr(serAttr)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := map[string]int{}
+	for path, slot := range map[string]int{
+		paths.IndexAPath: utils.SlotIndexA,
+		paths.IndexBPath: utils.SlotIndexB,
+		paths.ValueAPath: utils.SlotValueA,
+		paths.ValueBPath: utils.SlotValueB,
+	} {
+		if path != "" {
+			slots[path] = slot
+		}
+	}
+	return slots, nil
+}
+
+func slotIndex(slots map[string]int, field string) (int, error) {
+	slot, ok := slots[field]
+	if !ok {
+		return 0, fmt.Errorf("field %s is not in serialization attribute", field)
+	}
+	return slot, nil
+}
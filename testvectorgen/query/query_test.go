@@ -0,0 +1,216 @@
+package query
+
+import (
+	"math/big"
+	"testing"
+
+	"test/utils"
+
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	schemautils "github.com/iden3/go-schema-processor/v2/utils"
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+const testContextURL = "https://example.com/kyc.jsonld"
+
+const testContext = `{
+  "@context": {
+    "@version": 1.1,
+    "@protected": true,
+    "id": "@id",
+    "type": "@type",
+    "KYCAgeCredential": {
+      "@id": "https://example.com/kyc#KYCAgeCredential",
+      "@context": {
+        "@version": 1.1,
+        "@protected": true,
+        "id": "@id",
+        "type": "@type",
+        "kyc": "https://example.com/kyc#",
+        "xsd": "http://www.w3.org/2001/XMLSchema#",
+        "birthday": {"@id": "kyc:birthday", "@type": "xsd:integer"},
+        "residentSince": {"@id": "kyc:residentSince", "@type": "xsd:dateTime"},
+        "gender": {"@id": "kyc:gender", "@type": "xsd:string"}
+      }
+    },
+    "KYCAgeCredentialSlots": {
+      "@id": "https://example.com/kyc#KYCAgeCredentialSlots",
+      "@context": {
+        "@version": 1.1,
+        "@protected": true,
+        "id": "@id",
+        "type": "@type",
+        "iden3_serialization": "iden3:v1:slotIndexA=birthday&slotValueB=gender",
+        "kyc": "https://example.com/kyc#",
+        "xsd": "http://www.w3.org/2001/XMLSchema#",
+        "birthday": {"@id": "kyc:birthday", "@type": "xsd:integer"},
+        "gender": {"@id": "kyc:gender", "@type": "xsd:string"}
+      }
+    }
+  }
+}`
+
+func compile(t *testing.T, request string) *Query {
+	t.Helper()
+
+	req, err := ParseRequest([]byte(request))
+	require.NoError(t, err)
+	q, err := Compile(req, WithDocument(testContextURL, []byte(testContext)))
+	require.NoError(t, err)
+	return q
+}
+
+func Test_CompileMerklized(t *testing.T) {
+	q := compile(t, `{
+		"context": "https://example.com/kyc.jsonld",
+		"type": "KYCAgeCredential",
+		"credentialSubject": {"birthday": {"$lt": 20000101}}
+	}`)
+
+	path, err := merklize.NewPath(
+		"https://www.w3.org/2018/credentials#credentialSubject",
+		"https://example.com/kyc#birthday")
+	require.NoError(t, err)
+	pathKey, err := path.MtEntry()
+	require.NoError(t, err)
+
+	schemaHash := schemautils.CreateSchemaHash([]byte("https://example.com/kyc#KYCAgeCredential"))
+	require.Equal(t, schemaHash.BigInt().String(), q.ClaimSchema)
+	require.Equal(t, pathKey.String(), q.ClaimPathKey)
+	require.Equal(t, 1, q.Merklized)
+	require.Equal(t, 0, q.SlotIndex)
+	require.Equal(t, utils.LT, q.Operator)
+	require.Equal(t, ld.XSDInteger, q.Datatype)
+	require.Equal(t, 1, q.ValueArraySize)
+	require.Len(t, q.Value, ValueArraySize)
+	require.Equal(t, "20000101", q.Value[0])
+	require.Equal(t, 1, q.IsRevocationChecked)
+}
+
+func Test_CompileSlots(t *testing.T) {
+	q := compile(t, `{
+		"context": "https://example.com/kyc.jsonld",
+		"type": "KYCAgeCredentialSlots",
+		"credentialSubject": {"gender": {"$in": ["Male", "Female"]}},
+		"skipClaimRevocationCheck": true
+	}`)
+
+	require.Equal(t, "0", q.ClaimPathKey)
+	require.Equal(t, 0, q.Merklized)
+	require.Equal(t, utils.SlotValueB, q.SlotIndex)
+	require.Equal(t, utils.IN, q.Operator)
+	require.Equal(t, 2, q.ValueArraySize)
+	require.Equal(t, utils.QueryValues(t, ld.XSDString, "Male", "Female"), q.Value[:2])
+	require.Equal(t, 0, q.IsRevocationChecked)
+}
+
+func Test_CompileOperators(t *testing.T) {
+	cases := []struct {
+		Query    string
+		Operator int
+		Value    []string
+	}{
+		{`{}`, utils.NOOP, nil},
+		{`{"birthday": {}}`, utils.SD, nil},
+		{`{"birthday": {"$eq": -1}}`, utils.EQ, utils.QueryValues(t, ld.XSDInteger, -1)},
+		{`{"birthday": {"$between": [19900101, 20000101]}}`, utils.BETWEEN, []string{"19900101", "20000101"}},
+		{`{"birthday": {"$nonbetween": [19900101, 20000101]}}`, utils.NOT_BETWEEN, []string{"19900101", "20000101"}},
+		{`{"birthday": {"$exists": false}}`, utils.EXISTS, []string{"0"}},
+		{`{"residentSince": {"$gt": "2010-01-01"}}`, utils.GT, []string{"1262304000000000000"}},
+	}
+	for _, c := range cases {
+		q := compile(t, `{
+			"context": "https://example.com/kyc.jsonld",
+			"type": "KYCAgeCredential",
+			"credentialSubject": `+c.Query+`
+		}`)
+		require.Equal(t, c.Operator, q.Operator, c.Query)
+		require.Equal(t, len(c.Value), q.ValueArraySize, c.Query)
+		require.Equal(t, utils.PrepareStrArray(c.Value, ValueArraySize), q.Value, c.Query)
+	}
+}
+
+func Test_CompileErrors(t *testing.T) {
+	cases := []struct {
+		Type  string
+		Query string
+	}{
+		{"KYCAgeCredential", `{"birthday": {"$lt": 1}, "gender": {}}`},
+		{"KYCAgeCredential", `{"birthday": {"$lt": 1, "$gt": 0}}`},
+		{"KYCAgeCredential", `{"birthday": {"$like": 1}}`},
+		{"KYCAgeCredential", `{"birthday": {"$between": [1]}}`},
+		{"KYCAgeCredentialSlots", `{"birthday": {"$exists": true}}`},
+		{"KYCAgeCredentialSlots", `{"residentSince": {"$eq": 1}}`},
+	}
+	for _, c := range cases {
+		req, err := ParseRequest([]byte(`{
+			"context": "https://example.com/kyc.jsonld",
+			"type": "` + c.Type + `",
+			"credentialSubject": ` + c.Query + `
+		}`))
+		require.NoError(t, err)
+		_, err = Compile(req, WithDocument(testContextURL, []byte(testContext)))
+		require.Error(t, err, c.Query)
+	}
+}
+
+func Test_CircuitQueryHash(t *testing.T) {
+	q := compile(t, `{
+		"context": "https://example.com/kyc.jsonld",
+		"type": "KYCAgeCredential",
+		"credentialSubject": {"birthday": {"$lt": 20000101}}
+	}`)
+	h, err := q.CircuitQueryHash(big.NewInt(0), big.NewInt(0))
+	require.NoError(t, err)
+
+	// hash depends on the verifier and the nullifier session
+	h2, err := q.CircuitQueryHash(big.NewInt(1), big.NewInt(0))
+	require.NoError(t, err)
+	require.NotEqual(t, h, h2)
+	h3, err := q.CircuitQueryHash(big.NewInt(0), big.NewInt(1))
+	require.NoError(t, err)
+	require.NotEqual(t, h, h3)
+
+	// circuitQueryHash outputs of credentialAtomicQueryV3Universal vectors
+	// sig/in_operator and sig/nullify
+	verifierID, ok := new(big.Int).SetString(
+		"21929109382993718606847853573861987353620810345503358891473103689157378049", 10)
+	require.True(t, ok)
+	vectors := []struct {
+		Query     Query
+		SessionID int64
+		Expected  string
+	}{
+		{
+			Query{Inputs: Inputs{
+				ClaimSchema:         "180410020913331409885634153623124536270",
+				ClaimPathKey:        "0",
+				Operator:            utils.IN,
+				SlotIndex:           2,
+				Value:               utils.PrepareStrArray([]string{"8", "9", "10"}, 64),
+				ValueArraySize:      3,
+				IsRevocationChecked: 1,
+			}},
+			0,
+			"5674846522213524954491043532202272724698016514974605338469544826777234454707",
+		},
+		{
+			Query{Inputs: Inputs{
+				ClaimSchema:         "180410020913331409885634153623124536270",
+				ClaimPathKey:        "0",
+				Operator:            utils.NOOP,
+				SlotIndex:           2,
+				Value:               utils.PrepareStrArray([]string{}, 64),
+				IsRevocationChecked: 1,
+			}},
+			123,
+			"19807516177872076324802462820131296019241193150904977369555677697559725431535",
+		},
+	}
+	for _, v := range vectors {
+		h, err := v.Query.CircuitQueryHash(verifierID, big.NewInt(v.SessionID))
+		require.NoError(t, err)
+		require.Equal(t, v.Expected, h.String())
+	}
+}
@@ -0,0 +1,371 @@
+// Package rhs is an in-process stand-in for Reverse Hash Service used by
+// Iden3ReverseSparseMerkleTreeProof credential status. Server publishes nodes
+// of identity trees keyed by node hash, Client walks them to rebuild
+// non-revocation proofs, so the RHS path can be tested on one machine.
+package rhs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"test/utils"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+)
+
+// Node is a node of RHS: a hash with its preimage. State node has claims,
+// revocation and roots tree roots as children, middle node has left and
+// right children, leaf node has key, value and 1.
+type Node struct {
+	Hash     *merkletree.Hash
+	Children []*merkletree.Hash
+}
+
+type nodeJSON struct {
+	Hash     string   `json:"hash"`
+	Children []string `json:"children"`
+}
+
+// MarshalJSON encodes hashes as hex, the same way as RHS does
+func (n Node) MarshalJSON() ([]byte, error) {
+	j := nodeJSON{Hash: n.Hash.Hex(), Children: make([]string, len(n.Children))}
+	for i, c := range n.Children {
+		j.Children[i] = c.Hex()
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes hex hashes
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var j nodeJSON
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+	n.Hash, err = merkletree.NewHashFromHex(j.Hash)
+	if err != nil {
+		return err
+	}
+	n.Children = make([]*merkletree.Hash, len(j.Children))
+	for i, c := range j.Children {
+		n.Children[i], err = merkletree.NewHashFromHex(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Server is in-memory RHS serving GET /node/{hash} and POST /node
+type Server struct {
+	mu    sync.RWMutex
+	nodes map[merkletree.Hash]Node
+}
+
+// NewServer returns empty RHS
+func NewServer() *Server {
+	return &Server{nodes: map[merkletree.Hash]Node{}}
+}
+
+// Add adds nodes to RHS
+func (s *Server) Add(nodes ...Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, n := range nodes {
+		s.nodes[*n.Hash] = n
+	}
+}
+
+// Node returns node by hash
+func (s *Server) Node(hash *merkletree.Hash) (Node, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.nodes[*hash]
+	return n, ok
+}
+
+// Publish adds state node of the identity and all nodes of its claims,
+// revocation and roots trees
+func (s *Server) Publish(t testing.TB, it *utils.IdentityTest) {
+	t.Helper()
+
+	roots := []*merkletree.Hash{it.Clt.Root(), it.Ret.Root(), it.Rot.Root()}
+	state, err := merkletree.NewHashFromBigInt(it.State(t))
+	if err != nil {
+		t.Fatalf("failed convert state to hash: %v", err)
+	}
+	s.Add(Node{Hash: state, Children: roots})
+
+	for _, mt := range []*merkletree.MerkleTree{it.Clt, it.Ret, it.Rot} {
+		err := s.PublishTree(context.Background(), mt)
+		if err != nil {
+			t.Fatalf("failed publish tree: %v", err)
+		}
+	}
+}
+
+// PublishTree adds all middle and leaf nodes of the tree
+func (s *Server) PublishTree(ctx context.Context, mt *merkletree.MerkleTree) error {
+	var nodes []Node
+	var errIn error
+	err := mt.Walk(ctx, nil, func(n *merkletree.Node) {
+		var children []*merkletree.Hash
+		switch n.Type {
+		case merkletree.NodeTypeMiddle:
+			children = []*merkletree.Hash{n.ChildL, n.ChildR}
+		case merkletree.NodeTypeLeaf:
+			one, _ := merkletree.NewHashFromBigInt(big.NewInt(1))
+			children = []*merkletree.Hash{n.Entry[0], n.Entry[1], one}
+		default:
+			return
+		}
+		key, err := n.Key()
+		if err != nil {
+			errIn = err
+			return
+		}
+		nodes = append(nodes, Node{Hash: key, Children: children})
+	})
+	if err != nil {
+		return err
+	}
+	if errIn != nil {
+		return errIn
+	}
+	s.Add(nodes...)
+	return nil
+}
+
+type nodeResponse struct {
+	Node   *Node  `json:"node,omitempty"`
+	Status string `json:"status"`
+}
+
+// ServeHTTP implements RHS API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/node/"):
+		hash, err := merkletree.NewHashFromHex(strings.TrimPrefix(r.URL.Path, "/node/"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, nodeResponse{Status: err.Error()})
+			return
+		}
+		n, ok := s.Node(hash)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, nodeResponse{Status: "not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, nodeResponse{Node: &n, Status: "OK"})
+	case r.Method == http.MethodPost && r.URL.Path == "/node":
+		var nodes []Node
+		err := json.NewDecoder(r.Body).Decode(&nodes)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, nodeResponse{Status: err.Error()})
+			return
+		}
+		s.Add(nodes...)
+		writeJSON(w, http.StatusOK, nodeResponse{Status: "OK"})
+	default:
+		writeJSON(w, http.StatusNotFound, nodeResponse{Status: "not found"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Start serves new RHS on local HTTP server until the end of the test and
+// returns the RHS with client of the server
+func Start(t testing.TB) (*Server, *Client) {
+	t.Helper()
+
+	s := NewServer()
+	hs := httptest.NewServer(s)
+	t.Cleanup(hs.Close)
+	return s, &Client{URL: hs.URL, HTTPClient: hs.Client()}
+}
+
+// Client is RHS client
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// GetNode returns node by hash
+func (c *Client) GetNode(ctx context.Context, hash *merkletree.Hash) (Node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+"/node/"+hash.Hex(), nil)
+	if err != nil {
+		return Node{}, err
+	}
+	var resp nodeResponse
+	err = c.do(req, &resp)
+	if err != nil {
+		return Node{}, fmt.Errorf("failed get node %s: %w", hash.Hex(), err)
+	}
+	if resp.Node == nil {
+		return Node{}, fmt.Errorf("node %s is missing in response", hash.Hex())
+	}
+	return *resp.Node, nil
+}
+
+// SaveNodes publishes nodes to RHS
+func (c *Client) SaveNodes(ctx context.Context, nodes []Node) error {
+	body, err := json.Marshal(nodes)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/node", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, &nodeResponse{})
+}
+
+func (c *Client) do(req *http.Request, v *nodeResponse) error {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, v.Status)
+	}
+	return nil
+}
+
+// RevocationStatus rebuilds revocation status of the revocation nonce in the
+// identity state: the roots of the state and non-revocation proof walked
+// from the revocation tree root
+func (c *Client) RevocationStatus(ctx context.Context, state *big.Int, revNonce uint64) (verifiable.RevocationStatus, error) {
+	stateHash, err := merkletree.NewHashFromBigInt(state)
+	if err != nil {
+		return verifiable.RevocationStatus{}, err
+	}
+	stateNode, err := c.GetNode(ctx, stateHash)
+	if err != nil {
+		return verifiable.RevocationStatus{}, err
+	}
+	if len(stateNode.Children) != 3 {
+		return verifiable.RevocationStatus{}, fmt.Errorf("node %s is not a state node", stateHash.Hex())
+	}
+
+	proof, err := c.Proof(ctx, stateNode.Children[1], new(big.Int).SetUint64(revNonce))
+	if err != nil {
+		return verifiable.RevocationStatus{}, err
+	}
+
+	hexStr := func(h *merkletree.Hash) *string {
+		s := h.Hex()
+		return &s
+	}
+	return verifiable.RevocationStatus{
+		Issuer: verifiable.TreeState{
+			State:              hexStr(stateHash),
+			ClaimsTreeRoot:     hexStr(stateNode.Children[0]),
+			RevocationTreeRoot: hexStr(stateNode.Children[1]),
+			RootOfRoots:        hexStr(stateNode.Children[2]),
+		},
+		MTP: *proof,
+	}, nil
+}
+
+// Proof walks the tree from the root to the leaf of the key and returns
+// inclusion or non-inclusion proof of the key
+func (c *Client) Proof(ctx context.Context, root *merkletree.Hash, key *big.Int) (*merkletree.Proof, error) {
+	var siblings []*merkletree.Hash
+	next := root
+	for depth := 0; depth < utils.IdentityTreeLevels; depth++ {
+		if next.Equals(&merkletree.HashZero) {
+			return merkletree.NewProofFromData(false, siblings, nil)
+		}
+		n, err := c.GetNode(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		switch len(n.Children) {
+		case 2:
+			// path bits are taken from the least significant bit of the key
+			if key.Bit(depth) == 1 {
+				siblings = append(siblings, n.Children[0])
+				next = n.Children[1]
+			} else {
+				siblings = append(siblings, n.Children[1])
+				next = n.Children[0]
+			}
+		case 3:
+			if n.Children[0].BigInt().Cmp(key) == 0 {
+				return merkletree.NewProofFromData(true, siblings, nil)
+			}
+			return merkletree.NewProofFromData(false, siblings,
+				&merkletree.NodeAux{Key: n.Children[0], Value: n.Children[1]})
+		default:
+			return nil, fmt.Errorf("node %s is not a tree node", next.Hex())
+		}
+	}
+	return nil, fmt.Errorf("key %s is deeper than %d levels", key, utils.IdentityTreeLevels)
+}
+
+// NonRevInputs are non-revocation inputs of the issuer claim of credential
+// atomic query circuits
+type NonRevInputs struct {
+	IssuerClaimNonRevClaimsTreeRoot *merkletree.Hash `json:"issuerClaimNonRevClaimsTreeRoot"`
+	IssuerClaimNonRevRevTreeRoot    *merkletree.Hash `json:"issuerClaimNonRevRevTreeRoot"`
+	IssuerClaimNonRevRootsTreeRoot  *merkletree.Hash `json:"issuerClaimNonRevRootsTreeRoot"`
+	IssuerClaimNonRevState          string           `json:"issuerClaimNonRevState"`
+	IssuerClaimNonRevMtp            []string         `json:"issuerClaimNonRevMtp"`
+	IssuerClaimNonRevMtpAuxHi       string           `json:"issuerClaimNonRevMtpAuxHi"`
+	IssuerClaimNonRevMtpAuxHv       string           `json:"issuerClaimNonRevMtpAuxHv"`
+	IssuerClaimNonRevMtpNoAux       string           `json:"issuerClaimNonRevMtpNoAux"`
+}
+
+// NonRevInputs rebuilds non-revocation inputs of the revocation nonce in the
+// identity state
+func (c *Client) NonRevInputs(ctx context.Context, state *big.Int, revNonce uint64) (*NonRevInputs, error) {
+	status, err := c.RevocationStatus(ctx, state, revNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	var in NonRevInputs
+	for _, r := range []struct {
+		hex  *string
+		hash **merkletree.Hash
+	}{
+		{status.Issuer.ClaimsTreeRoot, &in.IssuerClaimNonRevClaimsTreeRoot},
+		{status.Issuer.RevocationTreeRoot, &in.IssuerClaimNonRevRevTreeRoot},
+		{status.Issuer.RootOfRoots, &in.IssuerClaimNonRevRootsTreeRoot},
+	} {
+		*r.hash, err = merkletree.NewHashFromHex(*r.hex)
+		if err != nil {
+			return nil, err
+		}
+	}
+	in.IssuerClaimNonRevState = state.String()
+
+	var aux utils.NodeAuxValue
+	in.IssuerClaimNonRevMtp, aux = utils.PrepareProof(&status.MTP, utils.IdentityTreeLevels)
+	in.IssuerClaimNonRevMtpAuxHi = aux.Key
+	in.IssuerClaimNonRevMtpAuxHv = aux.Value
+	in.IssuerClaimNonRevMtpNoAux = aux.NoAux
+	return &in, nil
+}
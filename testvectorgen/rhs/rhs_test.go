@@ -0,0 +1,101 @@
+package rhs
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"test/utils"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userPK   = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69e"
+	issuerPK = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69d"
+)
+
+func requireNonRevInputs(t *testing.T, issuer *utils.IdentityTest, in *NonRevInputs, revNonce uint64) {
+	t.Helper()
+
+	proof, _, err := issuer.Ret.GenerateProof(context.Background(), new(big.Int).SetUint64(revNonce), nil)
+	require.NoError(t, err)
+	mtp, aux := utils.PrepareProof(proof, utils.IdentityTreeLevels)
+
+	require.Equal(t, issuer.Clt.Root(), in.IssuerClaimNonRevClaimsTreeRoot)
+	require.Equal(t, issuer.Ret.Root(), in.IssuerClaimNonRevRevTreeRoot)
+	require.Equal(t, issuer.Rot.Root(), in.IssuerClaimNonRevRootsTreeRoot)
+	require.Equal(t, issuer.State(t).String(), in.IssuerClaimNonRevState)
+	require.Equal(t, mtp, in.IssuerClaimNonRevMtp)
+	require.Equal(t, aux.Key, in.IssuerClaimNonRevMtpAuxHi)
+	require.Equal(t, aux.Value, in.IssuerClaimNonRevMtpAuxHv)
+	require.Equal(t, aux.NoAux, in.IssuerClaimNonRevMtpNoAux)
+}
+
+func Test_NonRevInputs(t *testing.T) {
+	ctx := context.Background()
+	user := utils.NewIdentity(t, userPK)
+	issuer := utils.NewIdentity(t, issuerPK, utils.WithFillers(5))
+	claim := utils.DefaultUserClaim(t, user.ID, nil)
+	issuer.AddClaim(t, claim)
+
+	revoked := claim.GetRevocationNonce() + 1
+	err := issuer.Ret.Add(ctx, new(big.Int).SetUint64(revoked), big.NewInt(0))
+	require.NoError(t, err)
+
+	s, c := Start(t)
+	s.Publish(t, issuer)
+
+	// not revoked, proof ends in other leaf or empty node
+	in, err := c.NonRevInputs(ctx, issuer.State(t), claim.GetRevocationNonce())
+	require.NoError(t, err)
+	requireNonRevInputs(t, issuer, in, claim.GetRevocationNonce())
+
+	// revoked, proof of existence
+	in, err = c.NonRevInputs(ctx, issuer.State(t), revoked)
+	require.NoError(t, err)
+	require.Equal(t, "0", in.IssuerClaimNonRevMtpNoAux)
+	requireNonRevInputs(t, issuer, in, revoked)
+
+	status, err := c.RevocationStatus(ctx, issuer.State(t), revoked)
+	require.NoError(t, err)
+	require.True(t, status.MTP.Existence)
+
+	// unknown state
+	_, err = c.NonRevInputs(ctx, big.NewInt(1), revoked)
+	require.ErrorContains(t, err, "404")
+}
+
+func Test_NonRevInputsEmptyTree(t *testing.T) {
+	ctx := context.Background()
+	issuer := utils.NewIdentity(t, issuerPK)
+
+	s, c := Start(t)
+	s.Publish(t, issuer)
+
+	in, err := c.NonRevInputs(ctx, issuer.State(t), issuer.AuthClaim.GetRevocationNonce())
+	require.NoError(t, err)
+	require.Equal(t, "1", in.IssuerClaimNonRevMtpNoAux)
+	requireNonRevInputs(t, issuer, in, issuer.AuthClaim.GetRevocationNonce())
+}
+
+func Test_SaveNodes(t *testing.T) {
+	ctx := context.Background()
+	s, c := Start(t)
+
+	l, _ := merkletree.NewHashFromBigInt(big.NewInt(1))
+	r, _ := merkletree.NewHashFromBigInt(big.NewInt(2))
+	h, _ := merkletree.NewHashFromBigInt(big.NewInt(3))
+	n := Node{Hash: h, Children: []*merkletree.Hash{l, r}}
+
+	err := c.SaveNodes(ctx, []Node{n})
+	require.NoError(t, err)
+	got, ok := s.Node(h)
+	require.True(t, ok)
+	require.Equal(t, n, got)
+
+	got, err = c.GetNode(ctx, h)
+	require.NoError(t, err)
+	require.Equal(t, n, got)
+}
@@ -0,0 +1,248 @@
+// Package scenario generates random but valid identities, claims and queries
+// for property based testing of the circuits. All data is derived from the
+// seed, so a run can be reproduced from the seed it logged.
+package scenario
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"test/utils"
+
+	core "github.com/iden3/go-iden3-core/v2"
+)
+
+// SeedEnv is the environment variable to reproduce a run with
+const SeedEnv = "SCENARIO_SEED"
+
+// Rand is a seeded source of scenario data
+type Rand struct {
+	*rand.Rand
+	Seed int64
+}
+
+// New returns Rand for the seed
+func New(seed int64) *Rand {
+	return &Rand{Rand: rand.New(rand.NewSource(seed)), Seed: seed}
+}
+
+// FromEnv returns Rand seeded from SCENARIO_SEED environment variable, or
+// from the current time if it's not set. The seed is logged to reproduce
+// the run.
+func FromEnv(t testing.TB) *Rand {
+	t.Helper()
+
+	seed := time.Now().UnixNano()
+	if s := os.Getenv(SeedEnv); s != "" {
+		var err error
+		seed, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid %s: %v", SeedEnv, err)
+		}
+	}
+	t.Logf("%s=%d", SeedEnv, seed)
+	return New(seed)
+}
+
+// Bool returns true with probability 1/2
+func (r *Rand) Bool() bool {
+	return r.Intn(2) == 1
+}
+
+// BigInt returns random number of up to bits length
+func (r *Rand) BigInt(bits int) *big.Int {
+	b := make([]byte, (bits+7)/8)
+	r.Read(b)
+	n := new(big.Int).SetBytes(b)
+	return n.Rsh(n, uint(len(b)*8-bits))
+}
+
+// PrivateKey returns random BabyJubJub private key in hex
+func (r *Rand) PrivateKey() string {
+	b := make([]byte, 32)
+	r.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Nonce returns random non-zero nonce, e.g. profile nonce or link nonce
+func (r *Rand) Nonce() *big.Int {
+	n := r.BigInt(64)
+	return n.Add(n, big.NewInt(1))
+}
+
+// ProfileNonce returns 0 for genesis ID or random nonce of a profile
+func (r *Rand) ProfileNonce() *big.Int {
+	if r.Bool() {
+		return big.NewInt(0)
+	}
+	return r.Nonce()
+}
+
+// Timestamp returns random unix timestamp between 2020 and 2030
+func (r *Rand) Timestamp() int64 {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	return from + r.Int63n(to-from)
+}
+
+// Identity returns identity with random key and up to maxFillers random
+// claims in the claims tree and revoked nonces in the revocation tree, so
+// proofs have non-trivial siblings.
+func (r *Rand) Identity(t *testing.T, maxFillers int) *utils.IdentityTest {
+	t.Helper()
+
+	it := utils.NewIdentity(t, r.PrivateKey())
+	r.AddFillers(t, it, r.Intn(maxFillers+1))
+	return it
+}
+
+// AddFillers adds n random claims to the claims tree and n random revocation
+// nonces to the revocation tree of the identity. Fillers are added after the
+// identity ID is derived from its genesis state.
+func (r *Rand) AddFillers(t *testing.T, it *utils.IdentityTest, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		claim, err := core.NewClaim(r.SchemaHash(),
+			core.WithIndexDataInts(r.BigInt(248), r.BigInt(248)),
+			core.WithRevocationNonce(r.Uint64()))
+		if err != nil {
+			t.Fatalf("failed to create filler claim: %v", err)
+		}
+		it.AddClaim(t, claim)
+
+		err = it.Ret.Add(context.Background(), new(big.Int).SetUint64(r.Uint64()), big.NewInt(0))
+		if err != nil {
+			t.Fatalf("failed to add filler revocation nonce: %v", err)
+		}
+	}
+}
+
+// SchemaHash returns random schema hash
+func (r *Rand) SchemaHash() core.SchemaHash {
+	var sh core.SchemaHash
+	r.Read(sh[:])
+	return sh
+}
+
+// Claim is a random non-merklized claim with queried value in one of the
+// data slots
+type Claim struct {
+	Claim *core.Claim
+	// SlotIndex is the index of queried slot
+	SlotIndex int
+	// Value is the value of queried slot
+	Value *big.Int
+}
+
+// Claim returns random claim issued on the subject. Expiration date, if set,
+// is after the timestamp.
+func (r *Rand) Claim(t testing.TB, subject core.ID, timestamp int64) Claim {
+	t.Helper()
+
+	value := r.FieldValue()
+	other := r.BigInt(248)
+
+	// queried value is put to index or value data slot, the other slot of
+	// the pair is filled with random data
+	opts := []utils.ClaimOption{
+		utils.WithSubject(subject),
+		utils.WithRevocationNonce(r.Uint64()),
+		utils.WithVersion(r.Uint32()),
+	}
+	switch r.Intn(4) {
+	case 0:
+		opts = append(opts, utils.WithSlot(utils.SlotIndexA, value), utils.WithSlot(utils.SlotIndexB, other))
+	case 1:
+		opts = append(opts, utils.WithSlot(utils.SlotIndexB, value), utils.WithSlot(utils.SlotIndexA, other))
+	case 2:
+		opts = append(opts, utils.WithSlot(utils.SlotValueA, value), utils.WithSlot(utils.SlotValueB, other))
+	default:
+		opts = append(opts, utils.WithSlot(utils.SlotValueB, value), utils.WithSlot(utils.SlotValueA, other))
+	}
+
+	if r.Bool() {
+		expiration := timestamp + 1 + r.Int63n(10*365*24*3600)
+		opts = append(opts, utils.WithExpiration(time.Unix(expiration, 0)))
+	}
+	if r.Bool() {
+		opts = append(opts, utils.WithUpdatable(true))
+	}
+	opts = append(opts, utils.WithSchemaHash(r.SchemaHash()))
+
+	claim, slotIndex := utils.NewSlotClaim(t, opts...)
+	return Claim{Claim: claim, SlotIndex: slotIndex, Value: value}
+}
+
+// FieldValue returns random claim field value. Values are small or large,
+// but fit comparators of the query circuit (252 bits).
+func (r *Rand) FieldValue() *big.Int {
+	bits := []int{8, 32, 64, 128, 248}
+	return r.BigInt(bits[r.Intn(len(bits))])
+}
+
+// slotOperators are query operators supported by non-merklized claims
+var slotOperators = []int{
+	utils.NOOP, utils.EQ, utils.LT, utils.GT, utils.IN, utils.NIN, utils.NE,
+	utils.LTE, utils.GTE, utils.BETWEEN, utils.NOT_BETWEEN, utils.SD,
+}
+
+// Query returns random operator and values satisfied by the field value
+func (r *Rand) Query(fieldValue *big.Int) (int, []*big.Int) {
+	operator := slotOperators[r.Intn(len(slotOperators))]
+
+	// below returns random value less than v, above greater than v
+	below := func(v *big.Int) *big.Int {
+		if v.Sign() == 0 {
+			return nil
+		}
+		d := r.BigInt(v.BitLen())
+		return d.Mod(d, v)
+	}
+	above := func(v *big.Int) *big.Int {
+		d := r.BigInt(32)
+		return d.Add(d, v).Add(d, big.NewInt(1))
+	}
+
+	v := new(big.Int).Set(fieldValue)
+	switch operator {
+	case utils.EQ, utils.LTE, utils.GTE:
+		return operator, []*big.Int{v}
+	case utils.LT:
+		return operator, []*big.Int{above(v)}
+	case utils.GT:
+		if b := below(v); b != nil {
+			return operator, []*big.Int{b}
+		}
+		return utils.GTE, []*big.Int{v}
+	case utils.NE:
+		return operator, []*big.Int{above(v)}
+	case utils.IN, utils.NIN:
+		n := 1 + r.Intn(64)
+		values := make([]*big.Int, n)
+		for i := range values {
+			values[i] = above(v)
+		}
+		if operator == utils.IN {
+			values[r.Intn(n)] = v
+		}
+		return operator, values
+	case utils.BETWEEN:
+		from := below(v)
+		if from == nil {
+			from = big.NewInt(0)
+		}
+		return operator, []*big.Int{from, above(v)}
+	case utils.NOT_BETWEEN:
+		from := above(v)
+		return operator, []*big.Int{from, above(from)}
+	default:
+		return operator, []*big.Int{}
+	}
+}
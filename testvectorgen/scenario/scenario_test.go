@@ -0,0 +1,92 @@
+package scenario
+
+import (
+	"math/big"
+	"testing"
+
+	"test/utils"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Reproducible(t *testing.T) {
+	gen := func() (string, *big.Int, *core.Claim) {
+		r := New(7)
+		it := r.Identity(t, 20)
+		claim := r.Claim(t, it.ID, r.Timestamp())
+		return it.ID.String(), it.State(t), claim.Claim
+	}
+
+	id1, state1, claim1 := gen()
+	id2, state2, claim2 := gen()
+	require.Equal(t, id1, id2)
+	require.Equal(t, state1, state2)
+	require.Equal(t, claim1, claim2)
+}
+
+func Test_Claim(t *testing.T) {
+	r := New(1)
+	it := r.Identity(t, 0)
+	for i := 0; i < 20; i++ {
+		timestamp := r.Timestamp()
+		c := r.Claim(t, it.ID, timestamp)
+		require.Equal(t, c.Value, c.Claim.RawSlotsAsInts()[c.SlotIndex])
+
+		id, err := c.Claim.GetID()
+		require.NoError(t, err)
+		require.Equal(t, it.ID, id)
+
+		if exp, ok := c.Claim.GetExpirationDate(); ok {
+			require.Greater(t, exp.Unix(), timestamp)
+		}
+	}
+}
+
+func Test_Query(t *testing.T) {
+	r := New(3)
+	for i := 0; i < 500; i++ {
+		v := r.FieldValue()
+		op, values := r.Query(v)
+		require.True(t, satisfied(op, v, values), "operator %d, value %s, values %v", op, v, values)
+		require.Less(t, len(values), 65)
+	}
+}
+
+// satisfied evaluates the query like the query circuit does
+func satisfied(op int, v *big.Int, values []*big.Int) bool {
+	in := func() bool {
+		for _, x := range values {
+			if x.Cmp(v) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	switch op {
+	case utils.NOOP, utils.SD:
+		return len(values) == 0
+	case utils.EQ:
+		return v.Cmp(values[0]) == 0
+	case utils.LT:
+		return v.Cmp(values[0]) < 0
+	case utils.GT:
+		return v.Cmp(values[0]) > 0
+	case utils.LTE:
+		return v.Cmp(values[0]) <= 0
+	case utils.GTE:
+		return v.Cmp(values[0]) >= 0
+	case utils.NE:
+		return v.Cmp(values[0]) != 0
+	case utils.IN:
+		return in()
+	case utils.NIN:
+		return !in()
+	case utils.BETWEEN:
+		return v.Cmp(values[0]) >= 0 && v.Cmp(values[1]) <= 0
+	case utils.NOT_BETWEEN:
+		return v.Cmp(values[0]) < 0 || v.Cmp(values[1]) > 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,227 @@
+// Package snarkjs prepares test vectors for the snarkjs proving pipeline:
+// input.json with circuit inputs and public.json with expected public signals.
+package snarkjs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Signal is a signal declared in the main template of the circuit
+type Signal struct {
+	Name   string
+	Output bool
+	Array  bool
+}
+
+// Circuit is a circom main component with the signals of its template
+type Circuit struct {
+	Name     string
+	Template string
+	// Signals of the main template in declaration order
+	Signals []Signal
+	// Public inputs listed in the main component declaration
+	PublicInputs []string
+}
+
+var (
+	lineCommentRe  = regexp.MustCompile(`//[^\n]*`)
+	blockCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	mainRe         = regexp.MustCompile(`component\s+main\s*(?:\{\s*public\s*\[([^\]]*)\]\s*\})?\s*=\s*(\w+)\s*\(`)
+	includeRe      = regexp.MustCompile(`include\s+"([^"]+)"`)
+	signalRe       = regexp.MustCompile(`signal\s+(input|output)\s*(?:\{[^}]*\})?\s*([^;]+);`)
+)
+
+// ErrCircuitNotFound returns when circom file of the circuit can't be found
+var ErrCircuitNotFound = errors.New("circuit not found")
+
+// FindCircuit looks for `circuits/<name>.circom` in the current directory
+// and its parents and parses it.
+func FindCircuit(name string) (*Circuit, error) {
+	root, err := RootDir()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, name)
+	}
+	fileName := filepath.Join(root, "circuits", name+".circom")
+	if _, err := os.Stat(fileName); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitNotFound, name)
+	}
+	return ParseCircuit(fileName)
+}
+
+// RootDir returns repository root: the current directory or its closest
+// parent with circuits directory.
+func RootDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, "circuits")); err == nil && fi.IsDir() {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrCircuitNotFound
+		}
+		dir = parent
+	}
+}
+
+// ParseCircuit parses main component declaration of the circom file and
+// signals of the main template. Template is looked up in included files.
+func ParseCircuit(fileName string) (*Circuit, error) {
+	src, err := readSource(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	m := mainRe.FindStringSubmatch(src)
+	if m == nil {
+		return nil, fmt.Errorf("main component not found in %s", fileName)
+	}
+
+	c := &Circuit{
+		Name:     strings.TrimSuffix(filepath.Base(fileName), ".circom"),
+		Template: m[2],
+	}
+	for _, p := range strings.Split(m[1], ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			c.PublicInputs = append(c.PublicInputs, p)
+		}
+	}
+
+	body, err := findTemplate(fileName, c.Template)
+	if err != nil {
+		return nil, err
+	}
+	c.Signals = parseSignals(body)
+
+	return c, nil
+}
+
+// PublicSignals returns names of the public signals in the order snarkjs
+// puts them to public.json: outputs first, then public inputs, both in
+// declaration order of the main template.
+func (c *Circuit) PublicSignals() []string {
+	public := make(map[string]bool, len(c.PublicInputs))
+	for _, p := range c.PublicInputs {
+		public[p] = true
+	}
+
+	var outputs, inputs []string
+	for _, s := range c.Signals {
+		switch {
+		case s.Output:
+			outputs = append(outputs, s.Name)
+		case public[s.Name]:
+			inputs = append(inputs, s.Name)
+		}
+	}
+	return append(outputs, inputs...)
+}
+
+// Inputs returns names of the main template input signals
+func (c *Circuit) Inputs() []string {
+	var inputs []string
+	for _, s := range c.Signals {
+		if !s.Output {
+			inputs = append(inputs, s.Name)
+		}
+	}
+	return inputs
+}
+
+func readSource(fileName string) (string, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", err
+	}
+	src := blockCommentRe.ReplaceAllString(string(b), "")
+	return lineCommentRe.ReplaceAllString(src, ""), nil
+}
+
+// findTemplate returns body of the template declared in the file or in one of
+// the files it includes.
+func findTemplate(fileName, template string) (string, error) {
+	body, found, err := lookupTemplate(fileName, template, map[string]bool{})
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("template %s not found", template)
+	}
+	return body, nil
+}
+
+func lookupTemplate(fileName, template string, visited map[string]bool) (string, bool, error) {
+	if visited[fileName] {
+		return "", false, nil
+	}
+	visited[fileName] = true
+
+	src, err := readSource(fileName)
+	if err != nil {
+		return "", false, err
+	}
+
+	re := regexp.MustCompile(`template\s+` + regexp.QuoteMeta(template) + `\s*\([^)]*\)\s*\{`)
+	if loc := re.FindStringIndex(src); loc != nil {
+		return blockBody(src[loc[1]:]), true, nil
+	}
+
+	for _, inc := range includeRe.FindAllStringSubmatch(src, -1) {
+		incFile := filepath.Join(filepath.Dir(fileName), inc[1])
+		if _, err := os.Stat(incFile); err != nil {
+			// circomlib from node_modules may be not installed
+			continue
+		}
+		body, found, err := lookupTemplate(incFile, template, visited)
+		if err != nil || found {
+			return body, found, err
+		}
+	}
+
+	return "", false, nil
+}
+
+// blockBody returns text up to the brace closing already opened block
+func blockBody(src string) string {
+	depth := 1
+	for i, r := range src {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return src[:i]
+			}
+		}
+	}
+	return src
+}
+
+func parseSignals(body string) []Signal {
+	var signals []Signal
+	for _, m := range signalRe.FindAllStringSubmatch(body, -1) {
+		decl := m[2]
+		if i := strings.Index(decl, "<=="); i >= 0 {
+			decl = decl[:i]
+		}
+		for _, name := range strings.Split(decl, ",") {
+			name = strings.TrimSpace(name)
+			s := Signal{Name: name, Output: m[1] == "output"}
+			if i := strings.Index(name, "["); i >= 0 {
+				s.Name = strings.TrimSpace(name[:i])
+				s.Array = true
+			}
+			signals = append(signals, s)
+		}
+	}
+	return signals
+}
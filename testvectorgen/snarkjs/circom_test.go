@@ -0,0 +1,71 @@
+package snarkjs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PublicSignals(t *testing.T) {
+	tests := []struct {
+		circuit string
+		public  []string
+	}{
+		{"authV3", []string{"userID", "challenge", "gistRoot"}},
+		{"stateTransitionV3", []string{"userID", "oldUserState", "newUserState", "isOldStateGenesis"}},
+		{"credentialAtomicQueryV3OnChain", []string{
+			"userID", "circuitQueryHash", "issuerState", "linkID", "nullifier", "operatorOutput",
+			"proofType", "requestID", "challenge", "gistRoot", "issuerID", "issuerClaimNonRevState",
+			"timestamp", "isBJJAuthEnabled"}},
+		{"linkedMultiQuery", []string{"linkID", "merklized", "operatorOutput", "circuitQueryHash"}},
+		{"linkedMultiQuery3", []string{"linkID", "merklized", "operatorOutput", "circuitQueryHash"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.circuit, func(t *testing.T) {
+			c, err := FindCircuit(tt.circuit)
+			require.NoError(t, err)
+			require.Equal(t, tt.public, c.PublicSignals())
+		})
+	}
+}
+
+func Test_WriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "c.circom")
+	require.NoError(t, os.WriteFile(fileName, []byte(`
+template C() {
+    signal input a;
+    signal input b[2];
+    signal output c;
+}
+component main{public [a]} = C();
+`), 0644))
+	c, err := ParseCircuit(fileName)
+	require.NoError(t, err)
+
+	vector := []byte(`{
+		"inputs": {"a": "1", "b": ["2", "3"], "extra": "4"},
+		"expOut": {"c": "5"}
+	}`)
+	require.NoError(t, c.WriteFiles(dir, vector))
+
+	input, err := os.ReadFile(filepath.Join(dir, "input.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": "1", "b": ["2", "3"]}`, string(input))
+	public, err := os.ReadFile(filepath.Join(dir, "public.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `["5", "1"]`, string(public))
+
+	_, err = c.Input([]byte(`{"inputs": {"a": "1"}}`))
+	require.EqualError(t, err, "c: input signal b is missing")
+	_, err = c.ExpectedPublic([]byte(`{"inputs": {"b": ["2", "3"]}, "expOut": {"c": "5"}}`))
+	require.EqualError(t, err, "c: public signal a is missing")
+}
+
+func Test_FindCircuitNotFound(t *testing.T) {
+	_, err := FindCircuit("noSuchCircuit")
+	require.ErrorIs(t, err, ErrCircuitNotFound)
+}
@@ -0,0 +1,115 @@
+package snarkjs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type testVector struct {
+	Inputs map[string]json.RawMessage `json:"inputs"`
+	ExpOut map[string]json.RawMessage `json:"expOut"`
+}
+
+// Input returns input.json content for the test vector: inputs of the test
+// vector limited to the signals declared in the main template.
+func (c *Circuit) Input(vector []byte) ([]byte, error) {
+	var tv testVector
+	if err := json.Unmarshal(vector, &tv); err != nil {
+		return nil, err
+	}
+
+	inputs := make(map[string]json.RawMessage, len(tv.Inputs))
+	for _, name := range c.Inputs() {
+		v, ok := tv.Inputs[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: input signal %s is missing", c.Name, name)
+		}
+		inputs[name] = v
+	}
+	return json.Marshal(inputs)
+}
+
+// ExpectedPublic returns expected public.json content for the test vector in
+// the order of PublicSignals. Values are taken from expected outputs, public
+// inputs not present in outputs are taken from inputs.
+func (c *Circuit) ExpectedPublic(vector []byte) ([]string, error) {
+	var tv testVector
+	if err := json.Unmarshal(vector, &tv); err != nil {
+		return nil, err
+	}
+
+	var public []string
+	for _, name := range c.PublicSignals() {
+		v, ok := tv.ExpOut[name]
+		if !ok {
+			v, ok = tv.Inputs[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s: public signal %s is missing", c.Name, name)
+		}
+		values, err := flatten(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: public signal %s: %w", c.Name, name, err)
+		}
+		public = append(public, values...)
+	}
+	return public, nil
+}
+
+// WriteFiles writes input.json and public.json for the test vector to the dir
+func (c *Circuit) WriteFiles(dir string, vector []byte) error {
+	input, err := c.Input(vector)
+	if err != nil {
+		return err
+	}
+	public, err := c.ExpectedPublic(vector)
+	if err != nil {
+		return err
+	}
+	publicJSON, err := json.Marshal(public)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(dir, "input.json"), input, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "public.json"), publicJSON, 0644)
+}
+
+// flatten returns signal value as a flat list of decimal strings
+func flatten(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var res []string
+	var walk func(v any) error
+	walk = func(v any) error {
+		switch val := v.(type) {
+		case string:
+			res = append(res, val)
+		case json.Number:
+			res = append(res, val.String())
+		case []any:
+			for _, item := range val {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported value type %T", v)
+		}
+		return nil
+	}
+	return res, walk(v)
+}
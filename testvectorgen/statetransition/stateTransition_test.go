@@ -160,4 +160,5 @@ func generateAuthTestData(t *testing.T, genesis bool, desc, fileName string) {
 	require.NoError(t, err)
 
 	utils.SaveTestVector(t, fileName, string(json))
+	utils.SaveSnarkjsFiles(t, "stateTransitionV3", fileName, string(json))
 }
@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	schemautils "github.com/iden3/go-schema-processor/v2/utils"
+	"github.com/piprate/json-gold/ld"
+)
+
+// ClaimOption configures claim created by claim builders
+type ClaimOption func(*claimOptions)
+
+type claimOptions struct {
+	subject         *core.ID
+	subjectPosition core.IDPosition
+	slots           []slotValue
+	revNonce        uint64
+	expiration      *time.Time
+	version         uint32
+	updatable       bool
+	schemaHash      *core.SchemaHash
+	rootPosition    core.MerklizedRootPosition
+	documents       map[string][]byte
+}
+
+func newClaimOptions(opts []ClaimOption) claimOptions {
+	o := claimOptions{
+		subjectPosition: core.IDPositionIndex,
+		rootPosition:    core.MerklizedRootPositionIndex,
+		documents:       map[string][]byte{w3cSchemaURL: w3cSchemaBody},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// coreOptions returns options shared by all claims
+func (o claimOptions) coreOptions() []core.Option {
+	opts := []core.Option{
+		core.WithRevocationNonce(o.revNonce),
+		core.WithVersion(o.version),
+		core.WithFlagUpdatable(o.updatable),
+	}
+	if o.subject != nil && o.subjectPosition != core.IDPositionNone {
+		opts = append(opts, core.WithID(*o.subject, o.subjectPosition))
+	}
+	if o.expiration != nil {
+		opts = append(opts, core.WithExpirationDate(*o.expiration))
+	}
+	return opts
+}
+
+// WithSubject issues the claim on the subject, claims without subject are
+// self claims
+func WithSubject(id core.ID) ClaimOption {
+	return func(o *claimOptions) {
+		o.subject = &id
+	}
+}
+
+// WithSubjectPosition puts subject to index or value slots of the claim,
+// index by default. With core.IDPositionNone subject is not set, so the claim
+// is a self claim.
+func WithSubjectPosition(position core.IDPosition) ClaimOption {
+	return func(o *claimOptions) {
+		o.subjectPosition = position
+	}
+}
+
+// WithRevocationNonce sets revocation nonce of the claim, 0 by default
+func WithRevocationNonce(nonce uint64) ClaimOption {
+	return func(o *claimOptions) {
+		o.revNonce = nonce
+	}
+}
+
+// WithExpiration sets expiration date of the claim, claims don't expire by
+// default
+func WithExpiration(expiration time.Time) ClaimOption {
+	return func(o *claimOptions) {
+		o.expiration = &expiration
+	}
+}
+
+// WithVersion sets version of the claim
+func WithVersion(version uint32) ClaimOption {
+	return func(o *claimOptions) {
+		o.version = version
+	}
+}
+
+// WithUpdatable sets updatable flag of the claim
+func WithUpdatable(updatable bool) ClaimOption {
+	return func(o *claimOptions) {
+		o.updatable = updatable
+	}
+}
+
+// WithSchemaHash sets schema hash of the claim. Merklized claims take it from
+// the credential subject type by default, slot claims have empty schema hash.
+func WithSchemaHash(schemaHash core.SchemaHash) ClaimOption {
+	return func(o *claimOptions) {
+		o.schemaHash = &schemaHash
+	}
+}
+
+// WithRootPosition puts merklized root to index or value slots of the claim,
+// index by default
+func WithRootPosition(position core.MerklizedRootPosition) ClaimOption {
+	return func(o *claimOptions) {
+		o.rootPosition = position
+	}
+}
+
+// WithDocument makes JSON-LD document, e.g. a context of production schema,
+// available to merklizer without loading it from the url
+func WithDocument(url string, doc []byte) ClaimOption {
+	return func(o *claimOptions) {
+		o.documents[url] = doc
+	}
+}
+
+// Indexes of claim data slots
+const (
+	SlotIndexA = 2
+	SlotIndexB = 3
+	SlotValueA = 6
+	SlotValueB = 7
+)
+
+type slotValue struct {
+	slot  int
+	value any
+}
+
+// WithSlot sets data slot of non-merklized claim to the value. Values are
+// encoded as by issuers, the same way as values of merklized documents:
+// integers as is, strings and booleans hashed, time as unix nanoseconds.
+// Supported types are int, int64, string, bool, time.Time and *big.Int.
+func WithSlot(slot int, value any) ClaimOption {
+	return func(o *claimOptions) {
+		o.slots = append(o.slots, slotValue{slot: slot, value: value})
+	}
+}
+
+// NewSlotClaim creates non-merklized claim with data slots set by WithSlot.
+// It returns the claim and the index of the slot set first, which is the
+// slot to query.
+func NewSlotClaim(t testing.TB, opts ...ClaimOption) (*core.Claim, int) {
+	t.Helper()
+
+	o := newClaimOptions(opts)
+	if len(o.slots) == 0 {
+		t.Fatalf("claim has no data slots")
+	}
+
+	var slots [4]core.ElemBytes
+	for _, sv := range o.slots {
+		v := sv.value
+		if i, ok := v.(int); ok {
+			v = int64(i)
+		}
+		value, err := merklize.NewValue(merklize.PoseidonHasher{}, v)
+		if err != nil {
+			t.Fatalf("unsupported value %v of slot %d: %v", sv.value, sv.slot, err)
+		}
+		entry, err := value.MtEntry()
+		if err != nil {
+			t.Fatalf("failed encode value of slot %d: %v", sv.slot, err)
+		}
+
+		var i int
+		switch sv.slot {
+		case SlotIndexA:
+			i = 0
+		case SlotIndexB:
+			i = 1
+		case SlotValueA:
+			i = 2
+		case SlotValueB:
+			i = 3
+		default:
+			t.Fatalf("slot %d is not a data slot", sv.slot)
+		}
+		slots[i], err = core.NewElemBytesFromInt(entry)
+		if err != nil {
+			t.Fatalf("failed set slot %d: %v", sv.slot, err)
+		}
+	}
+
+	var schemaHash core.SchemaHash
+	if o.schemaHash != nil {
+		schemaHash = *o.schemaHash
+	}
+
+	claimOpts := append(o.coreOptions(),
+		core.WithIndexData(slots[0], slots[1]),
+		core.WithValueData(slots[2], slots[3]))
+	claim, err := core.NewClaim(schemaHash, claimOpts...)
+	if err != nil {
+		t.Fatalf("failed create new claim %v", err)
+	}
+
+	return claim, o.slots[0].slot
+}
+
+// NewMerklizedClaim merklizes W3C credential JSON-LD document and returns
+// merklizer with claim of the credential. Schema hash is derived from the
+// credential subject type as issuers do, if it's not set by WithSchemaHash.
+func NewMerklizedClaim(t testing.TB, credential string, opts ...ClaimOption) (*merklize.Merklizer, *core.Claim) {
+	t.Helper()
+
+	o := newClaimOptions(opts)
+
+	documentLoader, err := NewDocumentLoader(o.documents)
+	if err != nil {
+		t.Fatalf("failed init document loader: %v", err)
+	}
+
+	mz, err := merklize.MerklizeJSONLD(context.Background(), strings.NewReader(credential),
+		merklize.WithDocumentLoader(documentLoader))
+	if err != nil {
+		t.Fatalf("failed merklize credential: %v", err)
+	}
+
+	schemaHash := o.schemaHash
+	if schemaHash == nil {
+		typeID := credentialSubjectType(t, mz)
+		h := schemautils.CreateSchemaHash([]byte(typeID))
+		schemaHash = &h
+	}
+
+	claimOpts := append(o.coreOptions(), core.WithMerklizedRoot(mz.Root().BigInt(), o.rootPosition))
+	claim, err := core.NewClaim(*schemaHash, claimOpts...)
+	if err != nil {
+		t.Fatalf("failed generate core claim %v", err)
+	}
+
+	return mz, claim
+}
+
+// NewDocumentLoader returns JSON-LD document loader with the documents
+// embedded by their urls, other documents are loaded from network
+func NewDocumentLoader(documents map[string][]byte) (ld.DocumentLoader, error) {
+	cacheOpts := make([]loaders.MemoryCacheEngineOption, 0, len(documents))
+	for url, doc := range documents {
+		cacheOpts = append(cacheOpts, loaders.WithEmbeddedDocumentBytes(url, doc))
+	}
+	memoryCacheEngine, err := loaders.NewMemoryCacheEngine(cacheOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return loaders.NewDocumentLoader(nil, "https://ipfs.io/",
+		loaders.WithCacheEngine(memoryCacheEngine)), nil
+}
+
+// credentialSubjectType returns type of the credential the schema hash is
+// derived from. It follows findCredentialType of go-schema-processor:
+// credentialSubject.@type if it's a single type, otherwise the top level type
+// which is not VerifiableCredential.
+func credentialSubjectType(t testing.TB, mz *merklize.Merklizer) string {
+	t.Helper()
+
+	const (
+		credentialSubjectKey    = "https://www.w3.org/2018/credentials#credentialSubject"
+		verifiableCredentialKey = "https://www.w3.org/2018/credentials#VerifiableCredential"
+	)
+
+	subjectPath, err := mz.Options().NewPath(credentialSubjectKey, "@type")
+	if err == nil {
+		v, err := mz.RawValue(subjectPath)
+		if tp, ok := v.(string); err == nil && ok {
+			return tp
+		}
+	}
+
+	typePath, err := mz.Options().NewPath("@type")
+	if err != nil {
+		t.Fatalf("failed create type path: %v", err)
+	}
+	v, err := mz.RawValue(typePath)
+	if err != nil {
+		t.Fatalf("failed get credential type: %v", err)
+	}
+	types, _ := v.([]any)
+	if len(types) != 2 {
+		t.Fatalf("top level @type expected to be of length 2, got %v", v)
+	}
+	switch verifiableCredentialKey {
+	case types[0]:
+		tp, _ := types[1].(string)
+		return tp
+	case types[1]:
+		tp, _ := types[0].(string)
+		return tp
+	default:
+		t.Fatalf("@type(s) are expected to contain VerifiableCredential type")
+		return ""
+	}
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	schemautils "github.com/iden3/go-schema-processor/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+const testContextURL = "https://example.com/kyc.jsonld"
+
+const testContext = `{
+  "@context": {
+    "@version": 1.1,
+    "@protected": true,
+    "id": "@id",
+    "type": "@type",
+    "KYCAgeCredential": {
+      "@id": "https://example.com/kyc#KYCAgeCredential",
+      "@context": {
+        "@version": 1.1,
+        "@protected": true,
+        "id": "@id",
+        "type": "@type",
+        "kyc": "https://example.com/kyc#",
+        "birthday": {
+          "@id": "kyc:birthday",
+          "@type": "http://www.w3.org/2001/XMLSchema#integer"
+        }
+      }
+    }
+  }
+}`
+
+const testCredential = `{
+  "@context": [
+    "https://www.w3.org/2018/credentials/v1",
+    "https://example.com/kyc.jsonld"
+  ],
+  "id": "urn:uuid:1f1d3ad4-9a4b-4b8e-a0a3-6a0c2d8c5c2e",
+  "type": ["VerifiableCredential", "KYCAgeCredential"],
+  "issuer": "did:example:issuer",
+  "issuanceDate": "2024-01-01T00:00:00Z",
+  "credentialSubject": {
+    "id": "did:example:subject",
+    "type": "KYCAgeCredential",
+    "birthday": 19960424
+  }
+}`
+
+func Test_NewMerklizedClaim(t *testing.T) {
+	id := NewIdentity(t, userPK).ID
+	expiration := time.Unix(1893456000, 0)
+
+	mz, claim := NewMerklizedClaim(t, testCredential,
+		WithDocument(testContextURL, []byte(testContext)),
+		WithSubject(id),
+		WithRevocationNonce(42),
+		WithExpiration(expiration),
+		WithVersion(3),
+		WithUpdatable(true),
+		WithRootPosition(core.MerklizedRootPositionValue))
+
+	require.Equal(t, schemautils.CreateSchemaHash([]byte("https://example.com/kyc#KYCAgeCredential")),
+		claim.GetSchemaHash())
+
+	subject, err := claim.GetID()
+	require.NoError(t, err)
+	require.Equal(t, id, subject)
+	require.Equal(t, uint64(42), claim.GetRevocationNonce())
+	exp, ok := claim.GetExpirationDate()
+	require.True(t, ok)
+	require.Equal(t, expiration.Unix(), exp.Unix())
+	require.Equal(t, uint32(3), claim.GetVersion())
+	require.True(t, claim.GetFlagUpdatable())
+
+	position, err := claim.GetMerklizedPosition()
+	require.NoError(t, err)
+	require.Equal(t, core.MerklizedRootPositionValue, position)
+	root, err := claim.GetMerklizedRoot()
+	require.NoError(t, err)
+	require.Equal(t, mz.Root().BigInt(), root)
+
+	path, err := merklize.NewPath(
+		"https://www.w3.org/2018/credentials#credentialSubject",
+		"https://example.com/kyc#birthday")
+	require.NoError(t, err)
+	_, value, err := mz.Proof(context.Background(), path)
+	require.NoError(t, err)
+	v, err := value.MtEntry()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(19960424), v)
+}
+
+func Test_NewMerklizedClaimDefaults(t *testing.T) {
+	schemaHash, err := core.NewSchemaHashFromHex("ce6bb12c96bfd1544c02c289c6b4b987")
+	require.NoError(t, err)
+
+	_, claim := NewMerklizedClaim(t, testCredential,
+		WithDocument(testContextURL, []byte(testContext)),
+		WithSchemaHash(schemaHash))
+
+	require.Equal(t, schemaHash, claim.GetSchemaHash())
+	_, err = claim.GetID()
+	require.ErrorIs(t, err, core.ErrNoID)
+	_, ok := claim.GetExpirationDate()
+	require.False(t, ok)
+	position, err := claim.GetMerklizedPosition()
+	require.NoError(t, err)
+	require.Equal(t, core.MerklizedRootPositionIndex, position)
+}
+
+// Test_NewMerklizedClaimSubjectTypes checks schema hash of the credential
+// with multiple credential subject types is derived from the top level type
+// as go-schema-processor does
+func Test_NewMerklizedClaimSubjectTypes(t *testing.T) {
+	credential := strings.Replace(testCredential,
+		`"type": "KYCAgeCredential",`,
+		`"type": ["KYCAgeCredential", "https://schema.org/Person"],`, 1)
+	require.NotEqual(t, testCredential, credential)
+
+	_, claim := NewMerklizedClaim(t, credential, WithDocument(testContextURL, []byte(testContext)))
+
+	require.Equal(t, schemautils.CreateSchemaHash([]byte("https://example.com/kyc#KYCAgeCredential")),
+		claim.GetSchemaHash())
+}
+
+func Test_NewSlotClaim(t *testing.T) {
+	id := NewIdentity(t, userPK).ID
+	birthday := time.Date(1996, 4, 24, 0, 0, 0, 0, time.UTC)
+
+	claim, slot := NewSlotClaim(t,
+		WithSubject(id),
+		WithSubjectPosition(core.IDPositionValue),
+		WithSlot(SlotValueB, 19960424),
+		WithSlot(SlotIndexA, "Alice"),
+		WithSlot(SlotIndexB, true),
+		WithSlot(SlotValueA, birthday),
+		WithVersion(1),
+		WithUpdatable(true))
+	require.Equal(t, SlotValueB, slot)
+
+	position, err := claim.GetIDPosition()
+	require.NoError(t, err)
+	require.Equal(t, core.IDPositionValue, position)
+	subject, err := claim.GetID()
+	require.NoError(t, err)
+	require.Equal(t, id, subject)
+
+	// values are encoded as values of merklized documents
+	entry := func(v any) *big.Int {
+		value, err := merklize.NewValue(merklize.PoseidonHasher{}, v)
+		require.NoError(t, err)
+		e, err := value.MtEntry()
+		require.NoError(t, err)
+		return e
+	}
+	slots := claim.RawSlotsAsInts()
+	require.Equal(t, big.NewInt(19960424), slots[SlotValueB])
+	require.Equal(t, entry("Alice"), slots[SlotIndexA])
+	require.Equal(t, entry(true), slots[SlotIndexB])
+	require.Equal(t, entry(birthday), slots[SlotValueA])
+	require.Equal(t, uint32(1), claim.GetVersion())
+	require.True(t, claim.GetFlagUpdatable())
+}
@@ -0,0 +1,75 @@
+package utils
+
+import "time"
+
+// Clock is the current time of generated vectors, query timestamp and
+// expiration dates of claims are taken relative to it
+type Clock interface {
+	Now() time.Time
+}
+
+// FixedClock is a clock stopped at the time
+type FixedClock time.Time
+
+// Now returns the time of the clock
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// DefaultClock is the clock of query vectors, Thu Jan 13 2022 11:46:02 GMT+0000
+var DefaultClock Clock = FixedClock(time.Unix(1642074362, 0))
+
+// Expiration is the expiration date of a claim relative to the clock
+type Expiration int
+
+const (
+	// ExpirationDefault keeps the expiration date of the claim
+	ExpirationDefault Expiration = iota
+	// ExpirationNone makes the claim non-expiring
+	ExpirationNone
+	// ExpirationFuture expires the claim a year after now
+	ExpirationFuture
+	// ExpirationPast expires the claim a day before now
+	ExpirationPast
+	// ExpirationNow expires the claim exactly now, the claim is still valid
+	// at the timestamp equal to expiration date
+	ExpirationNow
+)
+
+func (e Expiration) String() string {
+	switch e {
+	case ExpirationNone:
+		return "none"
+	case ExpirationFuture:
+		return "future"
+	case ExpirationPast:
+		return "past"
+	case ExpirationNow:
+		return "now"
+	default:
+		return "default"
+	}
+}
+
+// WithRelativeExpiration sets expiration date of the claim relative to the
+// current time of the clock
+func WithRelativeExpiration(clock Clock, e Expiration) ClaimOption {
+	return func(o *claimOptions) {
+		now := clock.Now()
+		var expiration time.Time
+		switch e {
+		case ExpirationDefault:
+			return
+		case ExpirationNone:
+			o.expiration = nil
+			return
+		case ExpirationFuture:
+			expiration = now.AddDate(1, 0, 0)
+		case ExpirationPast:
+			expiration = now.AddDate(0, 0, -1)
+		case ExpirationNow:
+			expiration = now
+		}
+		o.expiration = &expiration
+	}
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithRelativeExpiration(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := FixedClock(now)
+	defaultExpiration := time.Unix(1669884010, 0)
+
+	cases := []struct {
+		Expiration Expiration
+		// Want is expected expiration date, 0 if the claim doesn't expire
+		Want int64
+	}{
+		{ExpirationDefault, defaultExpiration.Unix()},
+		{ExpirationNone, 0},
+		{ExpirationFuture, now.AddDate(1, 0, 0).Unix()},
+		{ExpirationPast, now.AddDate(0, 0, -1).Unix()},
+		{ExpirationNow, now.Unix()},
+	}
+	for _, c := range cases {
+		t.Run(c.Expiration.String(), func(t *testing.T) {
+			claim, _ := NewSlotClaim(t,
+				WithSlot(SlotIndexA, 1),
+				WithExpiration(defaultExpiration),
+				WithRelativeExpiration(clock, c.Expiration))
+
+			exp, ok := claim.GetExpirationDate()
+			if c.Want == 0 {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, c.Want, exp.Unix())
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/iden3/go-merkletree-sql/v2"
+)
+
+// EthIdentityTest is identity based on Ethereum address of the secp256k1
+// key. It has no BJJ auth claim, it's authenticated by the contracts with
+// msg.sender, which is the address of the key.
+type EthIdentityTest struct {
+	*IdentityTest
+	Key     *ecdsa.PrivateKey
+	Address common.Address
+}
+
+// NewEthIdentity returns identity of the secp256k1 private key in hex. Its
+// genesis ID is derived from the address of the key.
+func NewEthIdentity(t testing.TB, privKHex string) *EthIdentityTest {
+	key, err := crypto.HexToECDSA(privKHex)
+	if err != nil {
+		t.Fatalf("Error decoding secp256k1 private key: %v", err)
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	return &EthIdentityTest{
+		IdentityTest: NewEthereumBasedIdentity(t, addr.Hex()),
+		Key:          key,
+		Address:      addr,
+	}
+}
+
+// MsgSender is the sender of transactions of the identity
+func (it *EthIdentityTest) MsgSender() string {
+	return it.Address.Hex()
+}
+
+// Challenge is the challenge of auth disabled proofs: the address as little
+// endian number, the verifier contract checks it against msg.sender
+func (it *EthIdentityTest) Challenge() *big.Int {
+	return new(big.Int).SetBytes(merkletree.SwapEndianness(it.Address.Bytes()))
+}
+
+// SignChallenge signs keccak256 of the challenge as 32 bytes big endian
+// number, the hash a verifier contract gets with
+// keccak256(abi.encodePacked(challenge)). Signature is 65 bytes
+// [R || S || V] with V 27 or 28 as ecrecover expects it.
+func (it *EthIdentityTest) SignChallenge(t testing.TB, challenge *big.Int) []byte {
+	if challenge.Sign() < 0 || challenge.BitLen() > 256 {
+		t.Fatalf("Error signing challenge: %s is not uint256", challenge)
+	}
+	sig, err := crypto.Sign(challengeHash(challenge), it.Key)
+	if err != nil {
+		t.Fatalf("Error signing challenge: %v", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig
+}
+
+// RecoverChallengeSigner returns address of the key which signed the
+// challenge, the signature is the one of SignChallenge
+func RecoverChallengeSigner(challenge *big.Int, sig []byte) (common.Address, error) {
+	if challenge.Sign() < 0 || challenge.BitLen() > 256 {
+		return common.Address{}, errors.New("challenge is not uint256")
+	}
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("invalid signature length")
+	}
+	if v := sig[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+		return common.Address{}, errors.New("invalid signature recovery id")
+	}
+	raw := make([]byte, crypto.SignatureLength)
+	copy(raw, sig)
+	raw[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(challengeHash(challenge), raw)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+func challengeHash(challenge *big.Int) []byte {
+	return crypto.Keccak256(common.LeftPadBytes(challenge.Bytes(), 32))
+}
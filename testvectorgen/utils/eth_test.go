@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// hardhat accounts #0 and #1
+const (
+	ethPK0 = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	ethPK1 = "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+)
+
+func Test_NewEthIdentity(t *testing.T) {
+	it := NewEthIdentity(t, ethPK0)
+	require.Equal(t, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", it.MsgSender())
+
+	// ID is the same as of the identity of the address
+	require.Equal(t, NewEthereumBasedIdentity(t, it.MsgSender()).ID, it.ID)
+	addr, err := core.EthAddressFromID(it.ID)
+	require.NoError(t, err)
+	require.Equal(t, it.Address.Bytes(), addr[:])
+
+	// challenge is the address in little endian
+	b := it.Challenge().Bytes()
+	for i := range b {
+		require.Equal(t, it.Address[i], b[len(b)-1-i])
+	}
+}
+
+func Test_SignChallenge(t *testing.T) {
+	it := NewEthIdentity(t, ethPK0)
+	other := NewEthIdentity(t, ethPK1)
+	require.NotEqual(t, it.ID, other.ID)
+
+	sig := it.SignChallenge(t, it.Challenge())
+	require.Len(t, sig, 65)
+	require.Contains(t, []byte{27, 28}, sig[64])
+	signer, err := RecoverChallengeSigner(it.Challenge(), sig)
+	require.NoError(t, err)
+	require.Equal(t, it.Address, signer)
+
+	// signature of other challenge recovers other address
+	signer, err = RecoverChallengeSigner(other.Challenge(), sig)
+	require.NoError(t, err)
+	require.NotEqual(t, it.Address, signer)
+
+	_, err = RecoverChallengeSigner(big.NewInt(-1), sig)
+	require.Error(t, err)
+	_, err = RecoverChallengeSigner(it.Challenge(), sig[:64])
+	require.Error(t, err)
+}
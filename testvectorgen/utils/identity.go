@@ -20,6 +20,83 @@ type IdentityTest struct {
 	Rot       *merkletree.MerkleTree
 	AuthClaim *core.Claim
 	PK        *babyjub.PrivateKey
+
+	profiles []Profile
+}
+
+// Default profiles of test vectors: the profile the user proves with and
+// the profile the claim is issued on
+const (
+	UserProfile         = "user"
+	UserProfileNonce    = 10
+	SubjectProfile      = "subject"
+	SubjectProfileNonce = 999
+)
+
+// Profile is a named profile of identity
+type Profile struct {
+	Name  string
+	Nonce *big.Int
+	ID    core.ID
+}
+
+// AddProfile adds profile of the nonce and returns its ID. Adding the
+// profile again with the same name and nonce returns the same ID.
+func (it *IdentityTest) AddProfile(t testing.TB, name string, nonce *big.Int) core.ID {
+	if nonce == nil || nonce.Sign() == 0 {
+		t.Fatalf("profile %s: nonce 0 is the nonce of genesis ID", name)
+	}
+	for _, p := range it.profiles {
+		if p.Name != name {
+			continue
+		}
+		if p.Nonce.Cmp(nonce) != 0 {
+			t.Fatalf("profile %s exists with nonce %s", name, p.Nonce)
+		}
+		return p.ID
+	}
+
+	id, err := core.ProfileID(it.ID, nonce)
+	if err != nil {
+		t.Fatalf("Error creating profile %s: %v", name, err)
+	}
+	it.profiles = append(it.profiles, Profile{Name: name, Nonce: new(big.Int).Set(nonce), ID: id})
+	return id
+}
+
+// Profile returns profile by name
+func (it *IdentityTest) Profile(t testing.TB, name string) Profile {
+	for _, p := range it.profiles {
+		if p.Name == name {
+			return p
+		}
+	}
+	t.Fatalf("profile %s doesn't exist", name)
+	return Profile{}
+}
+
+// ProfileByID returns profile by its ID
+func (it *IdentityTest) ProfileByID(id core.ID) (Profile, bool) {
+	for _, p := range it.profiles {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// ProfileNonce returns nonce of the ID of the identity: 0 for genesis ID,
+// nonce of the profile for profile IDs. It's the nonce of profileNonce and
+// claimSubjectProfileNonce inputs.
+func (it *IdentityTest) ProfileNonce(t testing.TB, id core.ID) *big.Int {
+	if id == it.ID {
+		return big.NewInt(0)
+	}
+	p, ok := it.ProfileByID(id)
+	if !ok {
+		t.Fatalf("%s is not ID of the identity or its profiles", id.String())
+	}
+	return new(big.Int).Set(p.Nonce)
 }
 
 func (it *IdentityTest) Sign(challenge *big.Int) *babyjub.Signature {
@@ -127,7 +204,84 @@ func (it *IdentityTest) AddClaim(t *testing.T, claim *core.Claim) {
 	}
 }
 
-func NewIdentity(t testing.TB, privKHex string) *IdentityTest {
+// IdentityOption configures identity trees created by NewIdentity
+type IdentityOption func(*identityOptions)
+
+type identityOptions struct {
+	fillers    int
+	proofDepth int
+}
+
+// WithFillers adds n deterministic filler entries to the claims and
+// revocation trees of the identity, so proofs have non-empty siblings
+func WithFillers(n int) IdentityOption {
+	return func(o *identityOptions) {
+		o.fillers = n
+	}
+}
+
+// WithProofDepth extends the auth claim proofs of the identity in the claims
+// and revocation trees to depth levels, see DeepenPath. Depth must be less
+// than IdentityTreeLevels.
+func WithProofDepth(depth int) IdentityOption {
+	return func(o *identityOptions) {
+		o.proofDepth = depth
+	}
+}
+
+// DeepenClaimPath adds leaves to the claims tree so the proof of the claim
+// reaches depth levels
+func (it *IdentityTest) DeepenClaimPath(t testing.TB, claim *core.Claim, depth int) {
+	hi, _, err := claim.HiHv()
+	if err != nil {
+		t.Fatalf("can't get claim hash index %v", err)
+	}
+	DeepenPath(t, it.Clt, hi, depth)
+}
+
+// DeepenRevocationPath adds leaves to the revocation tree so the
+// non-revocation proof of the claim reaches depth levels
+func (it *IdentityTest) DeepenRevocationPath(t testing.TB, claim *core.Claim, depth int) {
+	revNonce := new(big.Int).SetUint64(claim.GetRevocationNonce())
+	DeepenPath(t, it.Ret, revNonce, depth)
+}
+
+// AddFillers adds n deterministic entries to the claims tree and n revoked
+// nonces to the revocation tree
+func (it *IdentityTest) AddFillers(t testing.TB, n int) {
+	for i := 0; i < n; i++ {
+		hi, err := poseidon.Hash([]*big.Int{big.NewInt(int64(i)), big.NewInt(1)})
+		if err != nil {
+			t.Fatalf("can't hash filler index %v", err)
+		}
+		hv, err := poseidon.Hash([]*big.Int{hi})
+		if err != nil {
+			t.Fatalf("can't hash filler value %v", err)
+		}
+		err = it.Clt.Add(context.Background(), hi, hv)
+		if err != nil {
+			t.Fatalf("Error adding filler to Claims merkle tree: %v", err)
+		}
+
+		nonce, err := poseidon.Hash([]*big.Int{big.NewInt(int64(i)), big.NewInt(2)})
+		if err != nil {
+			t.Fatalf("can't hash filler nonce %v", err)
+		}
+		// revocation nonces are uint64
+		nonce.SetUint64(nonce.Uint64())
+		err = it.Ret.Add(context.Background(), nonce, big.NewInt(0))
+		if err != nil {
+			t.Fatalf("Error adding filler to Revocation merkle tree: %v", err)
+		}
+	}
+}
+
+func NewIdentity(t testing.TB, privKHex string, opts ...IdentityOption) *IdentityTest {
+
+	var options identityOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
 
 	it := IdentityTest{}
 	var err error
@@ -160,6 +314,13 @@ func NewIdentity(t testing.TB, privKHex string) *IdentityTest {
 		t.Fatalf("Error adding Auth claim to Claims merkle tree: %v", err)
 	}
 
+	// trees are populated before ID is derived, so it is still the genesis ID
+	it.AddFillers(t, options.fillers)
+	if options.proofDepth > 0 {
+		it.DeepenClaimPath(t, authClaim, options.proofDepth)
+		it.DeepenRevocationPath(t, authClaim, options.proofDepth)
+	}
+
 	state := it.State(t)
 
 	identifier, err := IDFromState(state)
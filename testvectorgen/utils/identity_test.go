@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/stretchr/testify/require"
 )
 
@@ -43,3 +44,52 @@ func Test_IdentityData(t *testing.T) {
 
 	t.Log("DID:", did.String())
 }
+
+func Test_NewIdentityWithProofDepth(t *testing.T) {
+	depth := IdentityTreeLevels - 1
+	id := NewIdentity(t, userPK, WithFillers(50), WithProofDepth(depth))
+
+	// ID is derived from the populated genesis state
+	genesisID, err := IDFromState(id.State(t))
+	require.NoError(t, err)
+	require.Equal(t, *genesisID, id.ID)
+	require.NotEqual(t, NewIdentity(t, userPK).ID, id.ID)
+
+	p, _ := id.ClaimMTPRaw(t, id.AuthClaim)
+	require.True(t, p.Existence)
+	require.Len(t, p.AllSiblings(), depth)
+	for _, s := range p.AllSiblings() {
+		require.NotEqual(t, merkletree.HashZero, *s)
+	}
+
+	// auth claim is not revoked, the last level is the auxiliary node
+	p, _ = id.ClaimRevMTPRaw(t, id.AuthClaim)
+	require.False(t, p.Existence)
+	require.Len(t, p.AllSiblings(), depth-1)
+	require.NotNil(t, p.NodeAux)
+}
+
+func Test_Profiles(t *testing.T) {
+	it := NewIdentity(t, userPK)
+
+	userProfile := it.AddProfile(t, "user", big.NewInt(10))
+	subjectProfile := it.AddProfile(t, "subject", big.NewInt(999))
+	expected, err := core.ProfileID(it.ID, big.NewInt(10))
+	require.NoError(t, err)
+	require.Equal(t, expected, userProfile)
+	require.NotEqual(t, userProfile, subjectProfile)
+
+	// adding the same profile again returns its ID
+	require.Equal(t, userProfile, it.AddProfile(t, "user", big.NewInt(10)))
+
+	require.Equal(t, big.NewInt(999), it.Profile(t, "subject").Nonce)
+	p, ok := it.ProfileByID(subjectProfile)
+	require.True(t, ok)
+	require.Equal(t, "subject", p.Name)
+	_, ok = it.ProfileByID(it.ID)
+	require.False(t, ok)
+
+	require.Equal(t, "0", it.ProfileNonce(t, it.ID).String())
+	require.Equal(t, "10", it.ProfileNonce(t, userProfile).String())
+	require.Equal(t, "999", it.ProfileNonce(t, subjectProfile).String())
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
+)
+
+// DefaultVerifierDID is DID of the verifier of test vectors
+const DefaultVerifierDID = "did:iden3:readonly:tVDBfrPx6t1cALE5jmbQYxuyJXme65FF6LzPhZVhD"
+
+// VerifierID returns verifierID input of the verifier DID
+func VerifierID(did string) (*big.Int, error) {
+	d, err := w3c.ParseDID(did)
+	if err != nil {
+		return nil, err
+	}
+	id, err := core.IDFromDID(*d)
+	if err != nil {
+		return nil, err
+	}
+	return id.BigInt(), nil
+}
+
+// NullifierScenario is the nullifier of a proof: the user proves a claim of
+// the schema issued on its subject profile, to the verifier in the session.
+// Nullifier is the same for the same user, subject profile, schema, verifier
+// and session, and doesn't depend on the claim or on the profile the user
+// proves with.
+type NullifierScenario struct {
+	User *IdentityTest
+	// Subject is genesis ID of the user or one of its profiles
+	Subject     core.ID
+	ClaimSchema *big.Int
+	VerifierDID string
+	SessionID   *big.Int
+}
+
+// NewNullifierScenario returns scenario of the default verifier without
+// session, the claim is issued on genesis ID of the user
+func NewNullifierScenario(user *IdentityTest, claimSchema *big.Int) NullifierScenario {
+	return NullifierScenario{
+		User:        user,
+		Subject:     user.ID,
+		ClaimSchema: claimSchema,
+		VerifierDID: DefaultVerifierDID,
+		SessionID:   big.NewInt(0),
+	}
+}
+
+// WithSubject returns scenario of the claim issued on the subject
+func (s NullifierScenario) WithSubject(subject core.ID) NullifierScenario {
+	s.Subject = subject
+	return s
+}
+
+// WithVerifier returns scenario of the verifier DID
+func (s NullifierScenario) WithVerifier(did string) NullifierScenario {
+	s.VerifierDID = did
+	return s
+}
+
+// WithSession returns scenario of the nullifier session
+func (s NullifierScenario) WithSession(sessionID *big.Int) NullifierScenario {
+	s.SessionID = sessionID
+	return s
+}
+
+// VerifierID returns verifierID input of the verifier
+func (s NullifierScenario) VerifierID(t testing.TB) *big.Int {
+	id, err := VerifierID(s.VerifierDID)
+	if err != nil {
+		t.Fatalf("invalid verifier DID %s: %v", s.VerifierDID, err)
+	}
+	return id
+}
+
+// Nullifier returns nullifier output of the circuits
+func (s NullifierScenario) Nullifier(t testing.TB) string {
+	nullifier, err := CalculateNullify(
+		s.User.ID.BigInt(),
+		s.User.ProfileNonce(t, s.Subject),
+		s.ClaimSchema,
+		s.VerifierID(t),
+		s.SessionID,
+	)
+	if err != nil {
+		t.Fatalf("Error calculating nullifier: %v", err)
+	}
+	return nullifier
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifierID(t *testing.T) {
+	id, err := VerifierID(DefaultVerifierDID)
+	require.NoError(t, err)
+	require.Equal(t, "21929109382993718606847853573861987353620810345503358891473103689157378049", id.String())
+
+	_, err = VerifierID("verifier")
+	require.Error(t, err)
+}
+
+func Test_NullifierScenario(t *testing.T) {
+	user := NewIdentity(t, userPK)
+	profile := user.AddProfile(t, SubjectProfile, big.NewInt(SubjectProfileNonce))
+	other := user.AddProfile(t, "other", big.NewInt(1000))
+	otherDID, err := core.ParseDIDFromID(NewIdentity(t, userPK, WithFillers(1)).ID)
+	require.NoError(t, err)
+
+	base := NewNullifierScenario(user, big.NewInt(1)).WithSubject(profile).WithSession(big.NewInt(123))
+	nullifier := base.Nullifier(t)
+	require.NotEqual(t, "0", nullifier)
+	require.Equal(t, nullifier, base.WithSession(big.NewInt(123)).Nullifier(t))
+
+	for _, s := range []NullifierScenario{
+		base.WithSubject(other),
+		base.WithSession(big.NewInt(124)),
+		base.WithVerifier(otherDID.String()),
+	} {
+		require.NotEqual(t, nullifier, s.Nullifier(t))
+		require.NotEqual(t, "0", s.Nullifier(t))
+	}
+
+	// nullifier is 0 for genesis subject, without session or verifier
+	require.Equal(t, "0", base.WithSubject(user.ID).Nullifier(t))
+	require.Equal(t, "0", base.WithSession(big.NewInt(0)).Nullifier(t))
+	n, err := CalculateNullify(user.ID.BigInt(), big.NewInt(SubjectProfileNonce), big.NewInt(1),
+		big.NewInt(0), big.NewInt(123))
+	require.NoError(t, err)
+	require.Equal(t, "0", n)
+}
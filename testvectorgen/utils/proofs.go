@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"test/fixture"
+	"test/groth16"
+	"test/prover"
+	"test/pubsignals"
+	"test/snarkjs"
+)
+
+// VectorProver attaches proofs to generated test vectors. It is configured
+// with PROVER environment variable: "snarkjs" or "rapidsnark" (RAPIDSNARK
+// sets prover binary, "prover" from PATH by default). Proofs are not generated
+// if PROVER is not set.
+//
+// Circuits are expected to be compiled with compile-circuit.sh to
+// build/<circuitName>/ in the repository root, CIRCUITS_BUILD_DIR overrides
+// the build directory.
+var VectorProver = proverFromEnv()
+
+func proverFromEnv() prover.Prover {
+	switch os.Getenv("PROVER") {
+	case "snarkjs":
+		return prover.Exec{}
+	case "rapidsnark":
+		rapidsnark := os.Getenv("RAPIDSNARK")
+		if rapidsnark == "" {
+			rapidsnark = "prover"
+		}
+		return prover.Exec{Rapidsnark: rapidsnark}
+	default:
+		return nil
+	}
+}
+
+// circuitBuildDir returns directory with compiled circuit artifacts
+func circuitBuildDir(t *testing.T, circuitName string) string {
+	t.Helper()
+
+	if dir := os.Getenv("CIRCUITS_BUILD_DIR"); dir != "" {
+		return filepath.Join(dir, circuitName)
+	}
+	root, err := snarkjs.RootDir()
+	if err != nil {
+		t.Fatalf("Error looking for repository root: %v", err)
+	}
+	return filepath.Join(root, "build", circuitName)
+}
+
+// verificationKey returns verification key of the circuit if it was exported
+// to the build directory
+func verificationKey(t *testing.T, circuitName string) (*groth16.VerificationKey, bool) {
+	t.Helper()
+
+	fileName := filepath.Join(circuitBuildDir(t, circuitName), "verification_key.json")
+	if _, err := os.Stat(fileName); err != nil {
+		return nil, false
+	}
+	vk, err := groth16.LoadVerificationKey(fileName)
+	if err != nil {
+		t.Fatalf("Error reading verification key of %s: %v", circuitName, err)
+	}
+	return vk, true
+}
+
+// SaveSnarkjsFiles writes snarkjs input.json and expected public.json of the
+// test vector to testdata/snarkjs/<fileName>/. Public signals order is taken
+// from the main component of circuits/<circuitName>.circom and checked
+// against pubsignals layout of the circuit, which contract fixtures and proof
+// verification use.
+//
+// If VectorProver is configured the vector is also proven: proof.json is
+// written next to the inputs, public signals of the proof are checked against
+// expected and the proof is verified if verification key is available.
+func SaveSnarkjsFiles(t *testing.T, circuitName, fileName string, data string) {
+	t.Helper()
+
+	circuit, err := snarkjs.FindCircuit(circuitName)
+	if err != nil {
+		t.Fatalf("Error parsing circuit %s: %v", circuitName, err)
+	}
+	checkLayout(t, circuit, fileName, []byte(data))
+
+	dir := path.Join("testdata", "snarkjs", fileName)
+	err = circuit.WriteFiles(dir, []byte(data))
+	if err != nil {
+		t.Fatalf("Error writing snarkjs files for %s: %v", fileName, err)
+	}
+
+	if VectorProver == nil {
+		return
+	}
+
+	f, err := fixture.Build(context.Background(), VectorProver, circuitName,
+		zkeyFile(t, circuitName), []byte(data))
+	if err != nil {
+		t.Fatalf("Error proving %s: %v", fileName, err)
+	}
+	verifyFixture(t, circuitName, fileName, f)
+
+	proof, err := json.Marshal(f.Proof)
+	if err != nil {
+		t.Fatalf("Error encoding proof of %s: %v", fileName, err)
+	}
+	err = os.WriteFile(path.Join(dir, "proof.json"), proof, 0644)
+	if err != nil {
+		t.Fatalf("Error writing proof of %s: %v", fileName, err)
+	}
+}
+
+// SaveContractFixture proves the test vector with VectorProver and writes
+// contracts test fixture to test/validators/<destination>/ of the contracts
+// repository with the base name of the test vector file. The contracts
+// repository is expected next to this one in ../contracts, CONTRACTS_DIR
+// overrides its location.
+//
+// Fixtures are exported only with real proofs to the existing contracts
+// checkout: if VectorProver is not configured or the directory is not found
+// the fixture is skipped.
+func SaveContractFixture(t *testing.T, circuitName, destination, fileName string, data string) {
+	t.Helper()
+
+	if VectorProver == nil {
+		t.Logf("PROVER is not set, contract fixture %s is not exported", fileName)
+		return
+	}
+
+	dir := filepath.Join(contractsDir(t), "test", "validators", destination)
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		t.Logf("%s not found, contract fixture %s is not exported", dir, fileName)
+		return
+	}
+
+	f, err := fixture.Build(context.Background(), VectorProver, circuitName,
+		zkeyFile(t, circuitName), []byte(data))
+	if err != nil {
+		t.Fatalf("Error building contract fixture %s: %v", fileName, err)
+	}
+	verifyFixture(t, circuitName, fileName, f)
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("Error encoding contract fixture %s: %v", fileName, err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, path.Base(fileName)+".json"), b, 0644)
+	if err != nil {
+		t.Fatalf("Error writing contract fixture %s: %v", fileName, err)
+	}
+}
+
+// contractsDir returns directory of the contracts repository
+func contractsDir(t *testing.T) string {
+	t.Helper()
+
+	if dir := os.Getenv("CONTRACTS_DIR"); dir != "" {
+		return dir
+	}
+	root, err := snarkjs.RootDir()
+	if err != nil {
+		t.Fatalf("Error looking for repository root: %v", err)
+	}
+	return filepath.Join(filepath.Dir(root), "contracts")
+}
+
+// checkLayout fails if public signals of the test vector in the order of the
+// circuit declaration differ from the ones of pubsignals layout
+func checkLayout(t *testing.T, circuit *snarkjs.Circuit, fileName string, vector []byte) {
+	t.Helper()
+
+	layout, err := pubsignals.Get(circuit.Name)
+	if err != nil {
+		t.Fatalf("Error getting public signals layout of %s: %v", circuit.Name, err)
+	}
+	if !reflect.DeepEqual(circuit.PublicSignals(), layout.Names()) {
+		t.Fatalf("Public signals of %s circuit %v differ from pubsignals layout %v",
+			circuit.Name, circuit.PublicSignals(), layout.Names())
+	}
+
+	public, err := circuit.ExpectedPublic(vector)
+	if err != nil {
+		t.Fatalf("Error getting public signals of %s: %v", fileName, err)
+	}
+	expected, err := layout.FromVector(vector)
+	if err != nil {
+		t.Fatalf("Error getting public signals of %s: %v", fileName, err)
+	}
+	if len(public) != len(expected) {
+		t.Fatalf("%s has %d public signals of %s circuit, pubsignals layout has %d",
+			fileName, len(public), circuit.Name, len(expected))
+	}
+	for i := range expected {
+		if public[i] != expected[i].String() {
+			t.Fatalf("Public signal %d of %s is %s, pubsignals layout has %s",
+				i, fileName, public[i], expected[i])
+		}
+	}
+}
+
+func zkeyFile(t *testing.T, circuitName string) string {
+	t.Helper()
+	return filepath.Join(circuitBuildDir(t, circuitName), "circuit_final.zkey")
+}
+
+func verifyFixture(t *testing.T, circuitName, fileName string, f *fixture.Fixture) {
+	t.Helper()
+
+	vk, ok := verificationKey(t, circuitName)
+	if !ok {
+		return
+	}
+	if err := f.Verify(vk); err != nil {
+		t.Fatalf("Error verifying proof of %s: %v", fileName, err)
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"os"
+	"testing"
+
+	"test/pubsignals"
+	"test/snarkjs"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test_VectorCircuitLayouts checks public signals layouts of the circuits
+// generators pass to SaveSnarkjsFiles and SaveContractFixture are the ones
+// declared by main components of the circuits, linkedMultiQuery circuits are
+// checked by linked package.
+func Test_VectorCircuitLayouts(t *testing.T) {
+	circuits := []string{
+		"authV3",
+		"stateTransitionV3",
+		"credentialAtomicQueryV3",
+		"credentialAtomicQueryV3OnChain",
+		"credentialAtomicQueryV3Universal",
+	}
+	for _, circuit := range circuits {
+		c, err := snarkjs.FindCircuit(circuit)
+		require.NoError(t, err, circuit)
+		l, err := pubsignals.Get(circuit)
+		require.NoError(t, err, circuit)
+		require.Equal(t, c.PublicSignals(), l.Names(), circuit)
+	}
+}
+
+func Test_SaveContractFixtureWithoutProver(t *testing.T) {
+	if VectorProver != nil {
+		t.Skip("PROVER is set")
+	}
+	dir := t.TempDir()
+	t.Setenv("CONTRACTS_DIR", dir)
+	require.Equal(t, dir, contractsDir(t))
+
+	// fake proofs are not exported as contract fixtures
+	SaveContractFixture(t, "authV3", "common-data", "auth/fixture", `{}`)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
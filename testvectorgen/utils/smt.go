@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+)
+
+// ProofCase is the case of sparse merkle tree proof verified by SMTVerifier
+type ProofCase int
+
+const (
+	// ProofAny keeps the tree as is
+	ProofAny ProofCase = iota
+	// ProofInclusion proves the key is in the tree
+	ProofInclusion
+	// ProofNonInclusionAux proves the key is not in the tree, the path of
+	// the key ends at a leaf of another key (auxiliary node)
+	ProofNonInclusionAux
+	// ProofNonInclusionEmpty proves the key is not in the tree, the path of
+	// the key ends at an empty node
+	ProofNonInclusionEmpty
+)
+
+func (c ProofCase) String() string {
+	switch c {
+	case ProofInclusion:
+		return "inclusion"
+	case ProofNonInclusionAux:
+		return "non-inclusion with aux node"
+	case ProofNonInclusionEmpty:
+		return "non-inclusion with empty node"
+	default:
+		return "any"
+	}
+}
+
+// ProofCaseOf returns the case of the proof
+func ProofCaseOf(p *merkletree.Proof) ProofCase {
+	switch {
+	case p.Existence:
+		return ProofInclusion
+	case p.NodeAux != nil:
+		return ProofNonInclusionAux
+	default:
+		return ProofNonInclusionEmpty
+	}
+}
+
+// ForceProofCase adds leaves to the tree so the proof of the key is of the
+// case c. For inclusion the key is added with the value if it's not in the
+// tree, non-inclusion can't be forced for a key in the tree. It returns the
+// case of the proof, which is the current one for ProofAny.
+func ForceProofCase(t testing.TB, mt *merkletree.MerkleTree, key, value *big.Int, c ProofCase) ProofCase {
+	p, _, err := mt.GenerateProof(context.Background(), key, nil)
+	if err != nil {
+		t.Fatalf("can't generate proof %v", err)
+	}
+	current := ProofCaseOf(p)
+	if c == ProofAny || c == current {
+		return current
+	}
+
+	switch {
+	case c == ProofInclusion:
+		err = mt.Add(context.Background(), key, value)
+		if err != nil {
+			t.Fatalf("Error adding key to merkle tree: %v", err)
+		}
+	case current == ProofInclusion:
+		t.Fatalf("can't force %s proof, key %s is in the tree", c, key)
+	case c == ProofNonInclusionAux:
+		// put a leaf to the empty node at the end of the path
+		addLeaf(t, mt, branchKey(t, key, len(p.AllSiblings())))
+	default:
+		// split the path of the key and the aux node at the first bit they
+		// differ, then the node on the path of the key is empty
+		aux := p.NodeAux.Key.BigInt()
+		lvl := len(p.AllSiblings())
+		for key.Bit(lvl) == aux.Bit(lvl) {
+			lvl++
+		}
+		addLeaf(t, mt, branchKey(t, aux, lvl+1))
+	}
+
+	p, _, err = mt.GenerateProof(context.Background(), key, nil)
+	if err != nil {
+		t.Fatalf("can't generate proof %v", err)
+	}
+	if ProofCaseOf(p) != c {
+		t.Fatalf("failed to force %s proof, got %s", c, ProofCaseOf(p))
+	}
+	return c
+}
+
+// DeepenPath adds deterministic leaves to the tree so the proof of the key
+// reaches depth levels. For every level below depth a leaf is added that
+// shares the path of the key up to that level and branches off at it. The
+// inclusion proof then has depth non-empty siblings, for an absent key the
+// leaf of the last level is the auxiliary node of the non-inclusion proof.
+// Depth must be less than the number of levels of the tree.
+func DeepenPath(t testing.TB, mt *merkletree.MerkleTree, key *big.Int, depth int) {
+	if depth >= mt.MaxLevels() {
+		t.Fatalf("proof depth %d exceeds tree levels %d", depth, mt.MaxLevels())
+	}
+
+	for lvl := 0; lvl < depth; lvl++ {
+		addLeaf(t, mt, branchKey(t, key, lvl))
+	}
+}
+
+// branchKey returns deterministic key which shares the path of the key up to
+// the level and branches off at it
+func branchKey(t testing.TB, key *big.Int, lvl int) *big.Int {
+	k, err := poseidon.Hash([]*big.Int{key, big.NewInt(int64(lvl))})
+	if err != nil {
+		t.Fatalf("can't hash key %v", err)
+	}
+	// keep the key in the field after the low bits are replaced
+	k.SetBit(k, 253, 0)
+	// path bits are taken from the least significant bit, copy bits of the
+	// key below the level and flip the bit of the level
+	for i := 0; i < lvl; i++ {
+		k.SetBit(k, i, key.Bit(i))
+	}
+	return k.SetBit(k, lvl, key.Bit(lvl)^1)
+}
+
+func addLeaf(t testing.TB, mt *merkletree.MerkleTree, key *big.Int) {
+	v, err := poseidon.Hash([]*big.Int{key})
+	if err != nil {
+		t.Fatalf("can't hash value %v", err)
+	}
+	err = mt.Add(context.Background(), key, v)
+	if err != nil {
+		t.Fatalf("Error adding leaf to merkle tree: %v", err)
+	}
+}
+
+// MissingPath returns path of a credential subject field which is not in the
+// merklized document, with the non-inclusion proof of the case c
+func MissingPath(t testing.TB, mz *merklize.Merklizer, c ProofCase) merklize.Path {
+	for i := 0; i < 1000; i++ {
+		path, err := merklize.NewPath(
+			"https://www.w3.org/2018/credentials#credentialSubject",
+			fmt.Sprintf("https://w3id.org/citizenship#missing%d", i))
+		if err != nil {
+			t.Fatalf("failed to create path %v", err)
+		}
+		p, _, err := mz.Proof(context.Background(), path)
+		if err != nil {
+			t.Fatalf("failed to generate proof %v", err)
+		}
+		if ProofCaseOf(p) == c {
+			return path
+		}
+	}
+	t.Fatalf("no missing path with %s proof", c)
+	return merklize.Path{}
+}
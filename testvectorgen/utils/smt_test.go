@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTree(t *testing.T, levels int, keys ...int64) *merkletree.MerkleTree {
+	mt, err := merkletree.NewMerkleTree(context.Background(), memory.NewMemoryStorage(), levels)
+	require.NoError(t, err)
+	for _, k := range keys {
+		require.NoError(t, mt.Add(context.Background(), big.NewInt(k), big.NewInt(k)))
+	}
+	return mt
+}
+
+func Test_DeepenPath(t *testing.T) {
+	mt := newTestTree(t, GistLevels, 12345)
+
+	key := big.NewInt(12345)
+	DeepenPath(t, mt, key, GistLevels-1)
+
+	p, _, err := mt.GenerateProof(context.Background(), key, nil)
+	require.NoError(t, err)
+	require.True(t, p.Existence)
+	require.Len(t, p.AllSiblings(), GistLevels-1)
+}
+
+func Test_ForceProofCase(t *testing.T) {
+	key, value := big.NewInt(12345), big.NewInt(1)
+	cases := []ProofCase{ProofInclusion, ProofNonInclusionAux, ProofNonInclusionEmpty}
+
+	trees := map[string][]int64{
+		"empty":       nil,
+		"one leaf":    {1},
+		"many leaves": {1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	for name, keys := range trees {
+		for _, c := range cases {
+			t.Run(name+"/"+c.String(), func(t *testing.T) {
+				mt := newTestTree(t, IdentityTreeLevels, keys...)
+				require.Equal(t, c, ForceProofCase(t, mt, key, value, c))
+
+				p, v, err := mt.GenerateProof(context.Background(), key, nil)
+				require.NoError(t, err)
+				require.Equal(t, c, ProofCaseOf(p))
+				if c == ProofInclusion {
+					require.Equal(t, value, v)
+				}
+			})
+		}
+	}
+
+	mt := newTestTree(t, IdentityTreeLevels, 1)
+	require.Equal(t, ProofNonInclusionAux, ForceProofCase(t, mt, key, value, ProofAny))
+}
+
+func Test_MissingPath(t *testing.T) {
+	doc := `{
+		"@context": {"@vocab": "https://example.com/"},
+		"@type": "Person",
+		"name": "Alice",
+		"age": 30,
+		"city": "Madrid",
+		"email": "alice@example.com"
+	}`
+	mz, err := merklize.MerklizeJSONLD(context.Background(), strings.NewReader(doc))
+	require.NoError(t, err)
+
+	for _, c := range []ProofCase{ProofNonInclusionAux, ProofNonInclusionEmpty} {
+		path := MissingPath(t, mz, c)
+		p, v, err := mz.Proof(context.Background(), path)
+		require.NoError(t, err)
+		require.Nil(t, v)
+		require.Equal(t, c, ProofCaseOf(p))
+	}
+}
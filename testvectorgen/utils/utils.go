@@ -1,15 +1,12 @@
 package utils
 
 import (
-	"context"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"math/big"
 	"os"
 	"path"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
@@ -17,116 +14,62 @@ import (
 	"github.com/iden3/go-iden3-crypto/babyjub"
 	"github.com/iden3/go-iden3-crypto/poseidon"
 	"github.com/iden3/go-merkletree-sql/v2"
-	"github.com/iden3/go-schema-processor/v2/loaders"
 	"github.com/iden3/go-schema-processor/v2/merklize"
 )
 
-func DefaultJSONUserClaim(t testing.TB, subject core.ID) (*merklize.Merklizer, *core.Claim) {
-	opts := loaders.WithEmbeddedDocumentBytes(w3cSchemaURL, w3cSchemaBody)
-	memoryCacheEngine, err := loaders.NewMemoryCacheEngine(opts)
-	if err != nil {
-		log.Fatalf("failed init memory cache engine: %v", err)
-	}
-	documentLoader := loaders.NewDocumentLoader(nil, "https://ipfs.io/",
-		loaders.WithCacheEngine(memoryCacheEngine))
-
-	merklizeOpts := []merklize.MerklizeOption{
-		merklize.WithDocumentLoader(documentLoader),
-	}
-	mz, err := merklize.MerklizeJSONLD(context.Background(), strings.NewReader(TestClaimDocument), merklizeOpts...)
-	if err != nil {
-		t.Fatalf("failed marklize claim: %v", err)
-	}
-
-	var schemaHash core.SchemaHash
-	schemaBytes, err := hex.DecodeString("ce6bb12c96bfd1544c02c289c6b4b987")
+// DefaultJSONUserClaim returns merklized claim of TestClaimDocument issued on
+// the subject, opts override the defaults
+func DefaultJSONUserClaim(t testing.TB, subject core.ID, opts ...ClaimOption) (*merklize.Merklizer, *core.Claim) {
+	schemaHash, err := core.NewSchemaHashFromHex("ce6bb12c96bfd1544c02c289c6b4b987")
 	if err != nil {
 		t.Fatalf("failed decode schema hash string %v", err)
 	}
-	copy(schemaHash[:], schemaBytes)
 
-	nonce := 10
-
-	claim, err := core.NewClaim(
-		schemaHash,
-		core.WithIndexID(subject),
-		core.WithExpirationDate(time.Unix(1669884010, 0)), //Thu Dec 01 2022 08:40:10 GMT+0000
-		core.WithRevocationNonce(uint64(nonce)),
-		core.WithIndexMerklizedRoot(mz.Root().BigInt()))
-
-	if err != nil {
-		t.Fatalf("failed generate core claim %v", err)
-	}
-
-	return mz, claim
+	return NewMerklizedClaim(t, TestClaimDocument, append([]ClaimOption{
+		WithSubject(subject),
+		WithSchemaHash(schemaHash),
+		WithExpiration(time.Unix(1669884010, 0)), //Thu Dec 01 2022 08:40:10 GMT+0000
+		WithRevocationNonce(10),
+	}, opts...)...)
 }
 
-func DefaultJSONNormalUserClaim(t testing.TB, subject core.ID) (*merklize.Merklizer, *core.Claim) {
-	opts := loaders.WithEmbeddedDocumentBytes(w3cSchemaURL, w3cSchemaBody)
-	memoryCacheEngine, err := loaders.NewMemoryCacheEngine(opts)
-	if err != nil {
-		log.Fatalf("failed init memory cache engine: %v", err)
-	}
-	documentLoader := loaders.NewDocumentLoader(nil, "https://ipfs.io/",
-		loaders.WithCacheEngine(memoryCacheEngine))
-
-	merklizeOpts := []merklize.MerklizeOption{
-		merklize.WithDocumentLoader(documentLoader),
-	}
-	mz, err := merklize.MerklizeJSONLD(context.Background(), strings.NewReader(TestNormalClaimDocument), merklizeOpts...)
-	if err != nil {
-		t.Fatalf("failed marklize claim: %v", err)
-	}
-
+// DefaultJSONNormalUserClaim returns merklized claim of TestNormalClaimDocument
+// issued on the subject, opts override the defaults
+func DefaultJSONNormalUserClaim(t testing.TB, subject core.ID, opts ...ClaimOption) (*merklize.Merklizer, *core.Claim) {
 	schemaHash, err := core.NewSchemaHashFromHex("508991bcf0336ba99935ef498d797ec9")
 	if err != nil {
 		t.Fatalf("failed marklize claim: %v", err)
 	}
 
-	nonce := 10
-
-	claim, err := core.NewClaim(
-		schemaHash,
-		core.WithIndexID(subject),
-		core.WithExpirationDate(time.Unix(1669884010, 0)), //Thu Dec 01 2022 08:40:10 GMT+0000
-		core.WithRevocationNonce(uint64(nonce)),
-		core.WithIndexMerklizedRoot(mz.Root().BigInt()))
-
-	if err != nil {
-		t.Fatalf("failed generate core claim %v", err)
-	}
-
-	return mz, claim
+	return NewMerklizedClaim(t, TestNormalClaimDocument, append([]ClaimOption{
+		WithSubject(subject),
+		WithSchemaHash(schemaHash),
+		WithExpiration(time.Unix(1669884010, 0)), //Thu Dec 01 2022 08:40:10 GMT+0000
+		WithRevocationNonce(10),
+	}, opts...)...)
 }
 
 
-func DefaultUserClaim(t testing.TB, subject core.ID, subjValue *big.Int) *core.Claim {
+// DefaultUserClaim returns non-merklized claim issued on the subject with the
+// value in index slot A, opts override the defaults
+func DefaultUserClaim(t testing.TB, subject core.ID, subjValue *big.Int, opts ...ClaimOption) *core.Claim {
 	value := big.NewInt(10)
 	if subjValue != nil {
 		value = subjValue
 	}
-	dataSlotA, err := core.NewElemBytesFromInt(value)
-	if err != nil {
-		t.Fatalf("failed get NewElemBytesFromInt %v", err)
-	}
 
-	nonce := 1
-	var schemaHash core.SchemaHash
-	schemaBytes, err := hex.DecodeString("ce6bb12c96bfd1544c02c289c6b4b987")
+	schemaHash, err := core.NewSchemaHashFromHex("ce6bb12c96bfd1544c02c289c6b4b987")
 	if err != nil {
 		t.Fatalf("failed decode schema hash %v", err)
 	}
-	copy(schemaHash[:], schemaBytes)
 
-	claim, err := core.NewClaim(
-		schemaHash,
-		core.WithIndexID(subject),
-		core.WithIndexData(dataSlotA, core.ElemBytes{}),
-		core.WithExpirationDate(time.Unix(1669884010, 0)), //Thu Dec 01 2022 08:40:10 GMT+0000
-		core.WithRevocationNonce(uint64(nonce)))
-	if err != nil {
-		t.Fatalf("failed create new claim %v", err)
-	}
+	claim, _ := NewSlotClaim(t, append([]ClaimOption{
+		WithSubject(subject),
+		WithSchemaHash(schemaHash),
+		WithSlot(SlotIndexA, value),
+		WithExpiration(time.Unix(1669884010, 0)), //Thu Dec 01 2022 08:40:10 GMT+0000
+		WithRevocationNonce(1),
+	}, opts...)...)
 
 	return claim
 
@@ -353,12 +296,11 @@ func CalculateLinkID(linkNonce string, claim *core.Claim) (string, error) {
 	return linkID.String(), nil
 }
 
-// CalculateNullify returns nullify operator
+// CalculateNullify returns nullifier of credential atomic query V3 circuits.
+// Like the circuit, it returns 0 if the claim is issued on genesis ID, or
+// verifier ID or nullifier session ID is 0.
 func CalculateNullify(genesisID, claimSubjectProfileNonce, claimSchema, verifierID, nullifierSessionID *big.Int) (string, error) {
-	if claimSubjectProfileNonce == big.NewInt(0) {
-		return "0", nil
-	}
-	if verifierID == big.NewInt(0) {
+	if claimSubjectProfileNonce.Sign() == 0 || verifierID.Sign() == 0 || nullifierSessionID.Sign() == 0 {
 		return "0", nil
 	}
 
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/piprate/json-gold/ld"
+)
+
+// XSDDate is the datatype of xsd:date fields, merklize hashes them as strings
+const XSDDate = ld.XSDNS + "date"
+
+// EncodeValue encodes typed query value to the field element the circuit
+// compares against, the same way as merklize encodes values of the JSON-LD
+// datatype:
+//   - xsd:integer and other integer types as is, negative numbers as p-x
+//   - xsd:dateTime as unix nanoseconds, time.Time or "2006-01-02" strings
+//   - xsd:boolean as poseidon hash of 0 or 1
+//   - xsd:double as hash of the canonical double
+//   - strings, IRIs and other types as hash of the value
+//
+// Besides values supported by merklize, time.Time, *big.Int, unsigned
+// integers, DIDs and core.ID as DID are accepted.
+//
+// Circuit compares field elements as unsigned numbers, so negative numbers and
+// dates before 1970 are greater than non-negative ones.
+func EncodeValue(datatype string, value any) (*big.Int, error) {
+	switch v := value.(type) {
+	case time.Time:
+		if datatype == XSDDate {
+			value = v.Format("2006-01-02")
+		} else {
+			value = v.Format(time.RFC3339Nano)
+		}
+	case *big.Int:
+		value = v.String()
+	case uint, uint8, uint16, uint32, uint64:
+		value = fmt.Sprint(v)
+	case *w3c.DID:
+		value = v.String()
+	case core.ID:
+		did, err := core.ParseDIDFromID(v)
+		if err != nil {
+			return nil, err
+		}
+		value = did.String()
+	}
+	return merklize.HashValueWithHasher(merklize.PoseidonHasher{}, datatype, value)
+}
+
+// QueryValues encodes typed values of the datatype to query values
+func QueryValues(t testing.TB, datatype string, values ...any) []string {
+	t.Helper()
+
+	res := make([]string, len(values))
+	for i, v := range values {
+		e, err := EncodeValue(datatype, v)
+		if err != nil {
+			t.Fatalf("failed encode %v as %q: %v", v, datatype, err)
+		}
+		res[i] = e.String()
+	}
+	return res
+}
+
+// MerklizedQueryValues encodes typed values to query values of the path of the
+// merklized document, datatype is taken from the value of the path
+func MerklizedQueryValues(t testing.TB, mz *merklize.Merklizer, path merklize.Path, values ...any) []string {
+	t.Helper()
+
+	datatype, err := mz.JSONLDType(path)
+	if err != nil {
+		t.Fatalf("failed get datatype of path %v: %v", path.Parts(), err)
+	}
+	return QueryValues(t, datatype, values...)
+}
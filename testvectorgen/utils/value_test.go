@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+const testTypesContextURL = "https://example.com/types.jsonld"
+
+const testTypesContext = `{
+  "@context": {
+    "@version": 1.1,
+    "@protected": true,
+    "id": "@id",
+    "type": "@type",
+    "TypesCredential": {
+      "@id": "https://example.com/types#TypesCredential",
+      "@context": {
+        "@version": 1.1,
+        "@protected": true,
+        "id": "@id",
+        "type": "@type",
+        "tp": "https://example.com/types#",
+        "xsd": "http://www.w3.org/2001/XMLSchema#",
+        "balance": {"@id": "tp:balance", "@type": "xsd:integer"},
+        "residentSince": {"@id": "tp:residentSince", "@type": "xsd:dateTime"},
+        "birthDate": {"@id": "tp:birthDate", "@type": "xsd:date"},
+        "verified": {"@id": "tp:verified", "@type": "xsd:boolean"},
+        "score": {"@id": "tp:score", "@type": "xsd:double"},
+        "gender": {"@id": "tp:gender", "@type": "xsd:string"},
+        "issuedBy": {"@id": "tp:issuedBy", "@type": "@id"}
+      }
+    }
+  }
+}`
+
+const testTypesCredential = `{
+  "@context": [
+    "https://www.w3.org/2018/credentials/v1",
+    "https://example.com/types.jsonld"
+  ],
+  "id": "urn:uuid:0b9b1c1e-8f0a-4bde-9a1c-1c7a7c1a4f11",
+  "type": ["VerifiableCredential", "TypesCredential"],
+  "issuer": "did:example:issuer",
+  "issuanceDate": "2024-01-01T00:00:00Z",
+  "credentialSubject": {
+    "id": "did:example:subject",
+    "type": "TypesCredential",
+    "balance": -42,
+    "residentSince": "2015-01-01",
+    "birthDate": "1958-07-17",
+    "verified": true,
+    "score": 4.5,
+    "gender": "Male",
+    "issuedBy": "did:iden3:polygon:amoy:x6x5sor7zpxUwajVSoHGg8aAhoHNoAW1xFDTPCF49"
+  }
+}`
+
+func Test_MerklizedQueryValues(t *testing.T) {
+	mz, _ := NewMerklizedClaim(t, testTypesCredential,
+		WithDocument(testTypesContextURL, []byte(testTypesContext)))
+
+	did, err := w3c.ParseDID("did:iden3:polygon:amoy:x6x5sor7zpxUwajVSoHGg8aAhoHNoAW1xFDTPCF49")
+	require.NoError(t, err)
+
+	// typed values must be encoded as values of the document
+	cases := []struct {
+		Field string
+		Value any
+	}{
+		{"balance", -42},
+		{"balance", big.NewInt(-42)},
+		{"residentSince", time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"residentSince", "2015-01-01"},
+		{"birthDate", time.Date(1958, 7, 17, 0, 0, 0, 0, time.UTC)},
+		{"verified", true},
+		{"score", 4.5},
+		{"gender", "Male"},
+		{"issuedBy", did},
+	}
+	for _, c := range cases {
+		path, err := merklize.NewPath(
+			"https://www.w3.org/2018/credentials#credentialSubject",
+			"https://example.com/types#"+c.Field)
+		require.NoError(t, err)
+		_, value, err := mz.Proof(context.Background(), path)
+		require.NoError(t, err)
+		want, err := value.MtEntry()
+		require.NoError(t, err)
+
+		got := MerklizedQueryValues(t, mz, path, c.Value)
+		require.Equal(t, []string{want.String()}, got, "%s: %v", c.Field, c.Value)
+	}
+}
+
+func Test_EncodeValue(t *testing.T) {
+	prime := merklize.PoseidonHasher{}.Prime()
+
+	// negative numbers are p-x, see test/negativeNumbers.test.ts
+	v, err := EncodeValue(ld.XSDInteger, -1)
+	require.NoError(t, err)
+	require.Equal(t, new(big.Int).Sub(prime, big.NewInt(1)), v)
+
+	v, err = EncodeValue(ld.XSDInteger, uint64(18446744073709551615))
+	require.NoError(t, err)
+	require.Equal(t, "18446744073709551615", v.String())
+
+	// dates are unix nanoseconds
+	v, err = EncodeValue(ld.XSDNS+"dateTime", "2010-01-01")
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1262304000000000000), v)
+
+	_, err = EncodeValue(ld.XSDNS+"nonNegativeInteger", -1)
+	require.Error(t, err)
+	_, err = EncodeValue(ld.XSDBoolean, "yes")
+	require.Error(t, err)
+}
@@ -0,0 +1,389 @@
+// Package vc imports verifiable credentials issued by real issuers. It fills
+// issuer inputs of credential atomic query V3 circuits from BJJSignature2021
+// or Iden3SparseMerkleTreeProof proof of the credential, so a failure for a
+// credential a user holds can be reproduced by adding query and user inputs.
+package vc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"test/utils"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+)
+
+// IssuerInputs are issuer inputs of credential atomic query V3 circuits, JSON
+// names are the names of circuit signals
+type IssuerInputs struct {
+	IssuerID string `json:"issuerID"`
+	// Claim
+	IssuerClaim *core.Claim `json:"issuerClaim"`
+	// Inclusion
+	IssuerClaimMtp            []string         `json:"issuerClaimMtp"`
+	IssuerClaimClaimsTreeRoot *merkletree.Hash `json:"issuerClaimClaimsTreeRoot"`
+	IssuerClaimRevTreeRoot    *merkletree.Hash `json:"issuerClaimRevTreeRoot"`
+	IssuerClaimRootsTreeRoot  *merkletree.Hash `json:"issuerClaimRootsTreeRoot"`
+	IssuerClaimIdenState      string           `json:"issuerClaimIdenState"`
+
+	IssuerClaimNonRevClaimsTreeRoot *merkletree.Hash `json:"issuerClaimNonRevClaimsTreeRoot"`
+	IssuerClaimNonRevRevTreeRoot    *merkletree.Hash `json:"issuerClaimNonRevRevTreeRoot"`
+	IssuerClaimNonRevRootsTreeRoot  *merkletree.Hash `json:"issuerClaimNonRevRootsTreeRoot"`
+	IssuerClaimNonRevState          string           `json:"issuerClaimNonRevState"`
+	IssuerClaimNonRevMtp            []string         `json:"issuerClaimNonRevMtp"`
+	IssuerClaimNonRevMtpAuxHi       string           `json:"issuerClaimNonRevMtpAuxHi"`
+	IssuerClaimNonRevMtpAuxHv       string           `json:"issuerClaimNonRevMtpAuxHv"`
+	IssuerClaimNonRevMtpNoAux       string           `json:"issuerClaimNonRevMtpNoAux"`
+
+	ClaimSchema string `json:"claimSchema"`
+
+	// additional sig inputs
+	IssuerClaimSignatureR8X       string      `json:"issuerClaimSignatureR8x"`
+	IssuerClaimSignatureR8Y       string      `json:"issuerClaimSignatureR8y"`
+	IssuerClaimSignatureS         string      `json:"issuerClaimSignatureS"`
+	IssuerAuthClaim               *core.Claim `json:"issuerAuthClaim"`
+	IssuerAuthClaimMtp            []string    `json:"issuerAuthClaimMtp"`
+	IssuerAuthClaimNonRevMtp      []string    `json:"issuerAuthClaimNonRevMtp"`
+	IssuerAuthClaimNonRevMtpAuxHi string      `json:"issuerAuthClaimNonRevMtpAuxHi"`
+	IssuerAuthClaimNonRevMtpAuxHv string      `json:"issuerAuthClaimNonRevMtpAuxHv"`
+	IssuerAuthClaimNonRevMtpNoAux string      `json:"issuerAuthClaimNonRevMtpNoAux"`
+	IssuerAuthClaimsTreeRoot      string      `json:"issuerAuthClaimsTreeRoot"`
+	IssuerAuthRevTreeRoot         string      `json:"issuerAuthRevTreeRoot"`
+	IssuerAuthRootsTreeRoot       string      `json:"issuerAuthRootsTreeRoot"`
+	IssuerAuthState               string      `json:"issuerAuthState"`
+
+	ProofType string `json:"proofType"` // 1 for sig, 2 for mtp
+}
+
+// Status is revocation status of the credential claim and of the issuer auth
+// claim, as returned by the issuer or RHS for credentialStatus of the
+// credential and for credentialStatus of the issuer data of the proof
+type Status struct {
+	Claim verifiable.RevocationStatus
+	// AuthClaim is used by BJJSignature2021 proofs only
+	AuthClaim verifiable.RevocationStatus
+}
+
+// Parse parses W3C credential JSON
+func Parse(data []byte) (*verifiable.W3CCredential, error) {
+	var cred verifiable.W3CCredential
+	err := json.Unmarshal(data, &cred)
+	if err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// Import returns issuer inputs for the proof of the credential of the type:
+// verifiable.BJJSignatureProofType for signature inputs, or
+// verifiable.Iden3SparseMerkleTreeProofType for MTP inputs. The claim and the
+// auth claim proofs are checked against the issuer state of the proof,
+// non-revocation proofs against the revocation tree of the status. The circuit
+// checks non-revocation of the claim and of the auth claim at the same issuer
+// state, so both statuses must be at the same state.
+func Import(cred *verifiable.W3CCredential, proofType verifiable.ProofType, status Status) (*IssuerInputs, error) {
+	var proof verifiable.CredentialProof
+	for _, p := range cred.Proof {
+		if p.ProofType() == proofType {
+			proof = p
+			break
+		}
+	}
+	if proof == nil {
+		return nil, fmt.Errorf("credential has no %s proof", proofType)
+	}
+
+	var in *IssuerInputs
+	var err error
+	switch p := proof.(type) {
+	case *verifiable.BJJSignatureProof2021:
+		in, err = importSig(p, status)
+	case *verifiable.Iden3SparseMerkleTreeProof:
+		in, err = importMtp(p.IssuerData, p.CoreClaim, p.MTP)
+	case *verifiable.Iden3SparseMerkleProof:
+		in, err = importMtp(p.IssuerData, p.CoreClaim, p.MTP)
+	default:
+		return nil, fmt.Errorf("unsupported proof type %s", proofType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonRev, err := parseState(status.Claim.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("claim revocation status: %w", err)
+	}
+	in.IssuerClaimNonRevClaimsTreeRoot = nonRev.claimsTreeRoot
+	in.IssuerClaimNonRevRevTreeRoot = nonRev.revTreeRoot
+	in.IssuerClaimNonRevRootsTreeRoot = nonRev.rootsTreeRoot
+	in.IssuerClaimNonRevState = nonRev.state.BigInt().String()
+	err = verifyNonRevocation(nonRev.revTreeRoot, &status.Claim.MTP, in.IssuerClaim)
+	if err != nil {
+		return nil, fmt.Errorf("claim revocation status: %w", err)
+	}
+	var aux utils.NodeAuxValue
+	in.IssuerClaimNonRevMtp, aux = utils.PrepareProof(&status.Claim.MTP, utils.IdentityTreeLevels)
+	in.IssuerClaimNonRevMtpAuxHi = aux.Key
+	in.IssuerClaimNonRevMtpAuxHv = aux.Value
+	in.IssuerClaimNonRevMtpNoAux = aux.NoAux
+
+	in.ClaimSchema = in.IssuerClaim.GetSchemaHash().BigInt().String()
+	return in, nil
+}
+
+func importSig(p *verifiable.BJJSignatureProof2021, status Status) (*IssuerInputs, error) {
+	in, err := newIssuerInputs(p.IssuerData, p.CoreClaim)
+	if err != nil {
+		return nil, err
+	}
+
+	in.IssuerAuthClaim, err = parseClaim(p.IssuerData.AuthCoreClaim)
+	if err != nil {
+		return nil, fmt.Errorf("auth claim: %w", err)
+	}
+	if p.IssuerData.MTP == nil {
+		return nil, fmt.Errorf("issuer data has no auth claim proof")
+	}
+
+	authState, err := parseIssuerState(p.IssuerData.State)
+	if err != nil {
+		return nil, fmt.Errorf("issuer state: %w", err)
+	}
+	err = verifyInclusion(authState.claimsTreeRoot, p.IssuerData.MTP, in.IssuerAuthClaim)
+	if err != nil {
+		return nil, fmt.Errorf("auth claim: %w", err)
+	}
+	in.IssuerAuthClaimMtp, _ = utils.PrepareProof(p.IssuerData.MTP, utils.IdentityTreeLevels)
+
+	authNonRev, err := parseState(status.AuthClaim.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("auth claim revocation status: %w", err)
+	}
+	claimNonRev, err := parseState(status.Claim.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("claim revocation status: %w", err)
+	}
+	if !authNonRev.state.Equals(claimNonRev.state) {
+		return nil, fmt.Errorf("auth claim revocation status state %s differs from claim revocation status state %s",
+			authNonRev.state.Hex(), claimNonRev.state.Hex())
+	}
+	err = verifyNonRevocation(authNonRev.revTreeRoot, &status.AuthClaim.MTP, in.IssuerAuthClaim)
+	if err != nil {
+		return nil, fmt.Errorf("auth claim revocation status: %w", err)
+	}
+	var aux utils.NodeAuxValue
+	in.IssuerAuthClaimNonRevMtp, aux = utils.PrepareProof(&status.AuthClaim.MTP, utils.IdentityTreeLevels)
+	in.IssuerAuthClaimNonRevMtpAuxHi = aux.Key
+	in.IssuerAuthClaimNonRevMtpAuxHv = aux.Value
+	in.IssuerAuthClaimNonRevMtpNoAux = aux.NoAux
+	in.IssuerAuthClaimsTreeRoot = authState.claimsTreeRoot.BigInt().String()
+	in.IssuerAuthRevTreeRoot = authState.revTreeRoot.BigInt().String()
+	in.IssuerAuthRootsTreeRoot = authState.rootsTreeRoot.BigInt().String()
+	in.IssuerAuthState = authState.state.BigInt().String()
+
+	sig, err := parseSignature(p.Signature)
+	if err != nil {
+		return nil, err
+	}
+	err = verifySignature(in.IssuerClaim, in.IssuerAuthClaim, sig)
+	if err != nil {
+		return nil, err
+	}
+	in.IssuerClaimSignatureR8X = sig.R8.X.String()
+	in.IssuerClaimSignatureR8Y = sig.R8.Y.String()
+	in.IssuerClaimSignatureS = sig.S.String()
+
+	// claim inclusion is not proven by sig proofs
+	in.IssuerClaimMtp = utils.PrepareStrArray([]string{}, utils.IdentityTreeLevels)
+	in.IssuerClaimClaimsTreeRoot = &merkletree.HashZero
+	in.IssuerClaimRevTreeRoot = &merkletree.HashZero
+	in.IssuerClaimRootsTreeRoot = &merkletree.HashZero
+	in.IssuerClaimIdenState = "0"
+
+	in.ProofType = "1"
+	return in, nil
+}
+
+func importMtp(issuerData verifiable.IssuerData, coreClaim string, mtp *merkletree.Proof) (*IssuerInputs, error) {
+	in, err := newIssuerInputs(issuerData, coreClaim)
+	if err != nil {
+		return nil, err
+	}
+	if mtp == nil {
+		return nil, fmt.Errorf("proof has no claim mtp")
+	}
+
+	state, err := parseIssuerState(issuerData.State)
+	if err != nil {
+		return nil, fmt.Errorf("issuer state: %w", err)
+	}
+	err = verifyInclusion(state.claimsTreeRoot, mtp, in.IssuerClaim)
+	if err != nil {
+		return nil, fmt.Errorf("claim: %w", err)
+	}
+	in.IssuerClaimMtp, _ = utils.PrepareProof(mtp, utils.IdentityTreeLevels)
+	in.IssuerClaimClaimsTreeRoot = state.claimsTreeRoot
+	in.IssuerClaimRevTreeRoot = state.revTreeRoot
+	in.IssuerClaimRootsTreeRoot = state.rootsTreeRoot
+	in.IssuerClaimIdenState = state.state.BigInt().String()
+
+	// sig inputs are not used by mtp proofs
+	in.IssuerClaimSignatureR8X = "0"
+	in.IssuerClaimSignatureR8Y = "0"
+	in.IssuerClaimSignatureS = "0"
+	in.IssuerAuthClaim = &core.Claim{}
+	in.IssuerAuthClaimMtp = utils.PrepareStrArray([]string{}, utils.IdentityTreeLevels)
+	in.IssuerAuthClaimNonRevMtp = in.IssuerAuthClaimMtp
+	in.IssuerAuthClaimNonRevMtpAuxHi = "0"
+	in.IssuerAuthClaimNonRevMtpAuxHv = "0"
+	in.IssuerAuthClaimNonRevMtpNoAux = "0"
+	in.IssuerAuthClaimsTreeRoot = "0"
+	in.IssuerAuthRevTreeRoot = "0"
+	in.IssuerAuthRootsTreeRoot = "0"
+	in.IssuerAuthState = "0"
+
+	in.ProofType = "2"
+	return in, nil
+}
+
+// newIssuerInputs returns inputs with issuer ID and the claim of the proof
+func newIssuerInputs(issuerData verifiable.IssuerData, coreClaim string) (*IssuerInputs, error) {
+	did, err := w3c.ParseDID(issuerData.ID)
+	if err != nil {
+		return nil, fmt.Errorf("issuer DID: %w", err)
+	}
+	issuerID, err := core.IDFromDID(*did)
+	if err != nil {
+		return nil, fmt.Errorf("issuer DID: %w", err)
+	}
+	claim, err := parseClaim(coreClaim)
+	if err != nil {
+		return nil, fmt.Errorf("core claim: %w", err)
+	}
+	return &IssuerInputs{
+		IssuerID:    issuerID.BigInt().String(),
+		IssuerClaim: claim,
+	}, nil
+}
+
+func parseClaim(s string) (*core.Claim, error) {
+	var claim core.Claim
+	err := claim.FromHex(s)
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func parseSignature(s string) (*babyjub.Signature, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	var comp babyjub.SignatureComp
+	if len(b) != len(comp) {
+		return nil, fmt.Errorf("signature: invalid length %d", len(b))
+	}
+	copy(comp[:], b)
+	sig, err := comp.Decompress()
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	return sig, nil
+}
+
+// verifySignature checks the claim is signed by the key of the auth claim
+func verifySignature(claim, authClaim *core.Claim, sig *babyjub.Signature) error {
+	hi, hv, err := claim.HiHv()
+	if err != nil {
+		return err
+	}
+	h, err := poseidon.Hash([]*big.Int{hi, hv})
+	if err != nil {
+		return err
+	}
+	slots := authClaim.RawSlotsAsInts()
+	pk := babyjub.PublicKey{X: slots[2], Y: slots[3]}
+	if !pk.VerifyPoseidon(h, sig) {
+		return fmt.Errorf("claim signature is not valid for issuer auth claim")
+	}
+	return nil
+}
+
+// verifyInclusion checks the proof of the claim in the claims tree
+func verifyInclusion(claimsTreeRoot *merkletree.Hash, proof *merkletree.Proof, claim *core.Claim) error {
+	hi, hv, err := claim.HiHv()
+	if err != nil {
+		return err
+	}
+	if !proof.Existence || !merkletree.VerifyProof(claimsTreeRoot, proof, hi, hv) {
+		return fmt.Errorf("mtp doesn't prove inclusion in claims tree root %s", claimsTreeRoot.Hex())
+	}
+	return nil
+}
+
+// verifyNonRevocation checks the proof of the revocation nonce of the claim
+// in the revocation tree. Revoked nonces are added to the tree with value 0,
+// proofs of revoked claims are accepted, the circuit fails on them if
+// revocation is checked.
+func verifyNonRevocation(revTreeRoot *merkletree.Hash, proof *merkletree.Proof, claim *core.Claim) error {
+	nonce := new(big.Int).SetUint64(claim.GetRevocationNonce())
+	if !merkletree.VerifyProof(revTreeRoot, proof, nonce, big.NewInt(0)) {
+		return fmt.Errorf("mtp doesn't match revocation tree root %s", revTreeRoot.Hex())
+	}
+	return nil
+}
+
+// treeState is identity state with roots of the trees
+type treeState struct {
+	state, claimsTreeRoot, revTreeRoot, rootsTreeRoot *merkletree.Hash
+}
+
+func parseIssuerState(s verifiable.State) (treeState, error) {
+	return parseTreeState(s.Value, s.ClaimsTreeRoot, s.RevocationTreeRoot, s.RootOfRoots)
+}
+
+func parseState(s verifiable.TreeState) (treeState, error) {
+	return parseTreeState(s.State, s.ClaimsTreeRoot, s.RevocationTreeRoot, s.RootOfRoots)
+}
+
+// parseTreeState parses hex state and roots, state must be the hash of the
+// roots
+func parseTreeState(state, claimsTreeRoot, revTreeRoot, rootsTreeRoot *string) (treeState, error) {
+	var ts treeState
+	for _, h := range []struct {
+		name string
+		hex  *string
+		hash **merkletree.Hash
+	}{
+		{"state", state, &ts.state},
+		{"claims tree root", claimsTreeRoot, &ts.claimsTreeRoot},
+		{"revocation tree root", revTreeRoot, &ts.revTreeRoot},
+		{"roots tree root", rootsTreeRoot, &ts.rootsTreeRoot},
+	} {
+		if h.hex == nil {
+			return ts, fmt.Errorf("%s is missing", h.name)
+		}
+		hash, err := merkletree.NewHashFromHex(*h.hex)
+		if err != nil {
+			return ts, fmt.Errorf("%s: %w", h.name, err)
+		}
+		*h.hash = hash
+	}
+
+	expected, err := core.IdenState(ts.claimsTreeRoot.BigInt(), ts.revTreeRoot.BigInt(), ts.rootsTreeRoot.BigInt())
+	if err != nil {
+		return ts, err
+	}
+	if expected.Cmp(ts.state.BigInt()) != 0 {
+		return ts, fmt.Errorf("state %s is not the hash of the roots", *state)
+	}
+	return ts, nil
+}
@@ -0,0 +1,252 @@
+package vc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"test/utils"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userPK   = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69e"
+	issuerPK = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69d"
+)
+
+func hexHash(i *big.Int) *string {
+	h, _ := merkletree.NewHashFromBigInt(i)
+	s := h.Hex()
+	return &s
+}
+
+func issuerState(t *testing.T, issuer *utils.IdentityTest) verifiable.State {
+	return verifiable.State{
+		Value:              hexHash(issuer.State(t)),
+		ClaimsTreeRoot:     hexHash(issuer.Clt.Root().BigInt()),
+		RevocationTreeRoot: hexHash(issuer.Ret.Root().BigInt()),
+		RootOfRoots:        hexHash(issuer.Rot.Root().BigInt()),
+	}
+}
+
+func revocationStatus(t *testing.T, issuer *utils.IdentityTest, claim *core.Claim) verifiable.RevocationStatus {
+	proof, _ := issuer.ClaimRevMTPRaw(t, claim)
+	state := issuerState(t, issuer)
+	return verifiable.RevocationStatus{
+		Issuer: verifiable.TreeState{
+			State:              state.Value,
+			ClaimsTreeRoot:     state.ClaimsTreeRoot,
+			RevocationTreeRoot: state.RevocationTreeRoot,
+			RootOfRoots:        state.RootOfRoots,
+		},
+		MTP: *proof,
+	}
+}
+
+// credential returns credential JSON with the proof issued by the issuer
+func credential(t *testing.T, issuer *utils.IdentityTest, proof any) *verifiable.W3CCredential {
+	did, err := core.ParseDIDFromID(issuer.ID)
+	require.NoError(t, err)
+	b, err := json.Marshal(map[string]any{
+		"@context":          []string{"https://www.w3.org/2018/credentials/v1"},
+		"type":              []string{"VerifiableCredential"},
+		"issuer":            did.String(),
+		"credentialSubject": map[string]any{"id": "did:example:subject"},
+		"proof":             []any{proof},
+	})
+	require.NoError(t, err)
+	cred, err := Parse(b)
+	require.NoError(t, err)
+	return cred
+}
+
+func issuerData(t *testing.T, issuer *utils.IdentityTest) map[string]any {
+	did, err := core.ParseDIDFromID(issuer.ID)
+	require.NoError(t, err)
+	return map[string]any{
+		"id":    did.String(),
+		"state": issuerState(t, issuer),
+	}
+}
+
+func Test_ImportSig(t *testing.T) {
+	user := utils.NewIdentity(t, userPK)
+	issuer := utils.NewIdentity(t, issuerPK, utils.WithFillers(5))
+	claim := utils.DefaultUserClaim(t, user.ID, nil)
+
+	sig := issuer.SignClaim(t, claim)
+	comp := sig.Compress()
+	authMtp, _ := issuer.ClaimMTPRaw(t, issuer.AuthClaim)
+	authClaimHex, err := issuer.AuthClaim.Hex()
+	require.NoError(t, err)
+	claimHex, err := claim.Hex()
+	require.NoError(t, err)
+
+	data := issuerData(t, issuer)
+	data["authCoreClaim"] = authClaimHex
+	data["mtp"] = authMtp
+	cred := credential(t, issuer, map[string]any{
+		"type":       verifiable.BJJSignatureProofType,
+		"issuerData": data,
+		"coreClaim":  claimHex,
+		"signature":  hex.EncodeToString(comp[:]),
+	})
+
+	in, err := Import(cred, verifiable.BJJSignatureProofType, Status{
+		Claim:     revocationStatus(t, issuer, claim),
+		AuthClaim: revocationStatus(t, issuer, issuer.AuthClaim),
+	})
+	require.NoError(t, err)
+
+	// inputs are the same as generated for the issuer
+	issuerAuthClaimMtp, _ := issuer.ClaimMTP(t, issuer.AuthClaim)
+	issuerAuthClaimNonRevMtp, authAux := issuer.ClaimRevMTP(t, issuer.AuthClaim)
+	issuerClaimNonRevMtp, aux := issuer.ClaimRevMTP(t, claim)
+	require.Equal(t, issuer.ID.BigInt().String(), in.IssuerID)
+	require.Equal(t, claim, in.IssuerClaim)
+	require.Equal(t, "1", in.ProofType)
+	require.Equal(t, sig.R8.X.String(), in.IssuerClaimSignatureR8X)
+	require.Equal(t, sig.R8.Y.String(), in.IssuerClaimSignatureR8Y)
+	require.Equal(t, sig.S.String(), in.IssuerClaimSignatureS)
+	require.Equal(t, issuer.AuthClaim, in.IssuerAuthClaim)
+	require.Equal(t, issuerAuthClaimMtp, in.IssuerAuthClaimMtp)
+	require.Equal(t, issuerAuthClaimNonRevMtp, in.IssuerAuthClaimNonRevMtp)
+	require.Equal(t, authAux.NoAux, in.IssuerAuthClaimNonRevMtpNoAux)
+	require.Equal(t, issuer.State(t).String(), in.IssuerAuthState)
+	require.Equal(t, issuer.Clt.Root().BigInt().String(), in.IssuerAuthClaimsTreeRoot)
+	require.Equal(t, issuerClaimNonRevMtp, in.IssuerClaimNonRevMtp)
+	require.Equal(t, aux.NoAux, in.IssuerClaimNonRevMtpNoAux)
+	require.Equal(t, issuer.State(t).String(), in.IssuerClaimNonRevState)
+	require.Equal(t, issuer.Ret.Root(), in.IssuerClaimNonRevRevTreeRoot)
+	require.Equal(t, "0", in.IssuerClaimIdenState)
+	require.Equal(t, claim.GetSchemaHash().BigInt().String(), in.ClaimSchema)
+
+	// claim signed by other key is rejected
+	other := utils.NewIdentity(t, userPK)
+	comp = other.SignClaim(t, claim).Compress()
+	cred.Proof[0].(*verifiable.BJJSignatureProof2021).Signature = hex.EncodeToString(comp[:])
+	_, err = Import(cred, verifiable.BJJSignatureProofType, Status{
+		Claim:     revocationStatus(t, issuer, claim),
+		AuthClaim: revocationStatus(t, issuer, issuer.AuthClaim),
+	})
+	require.ErrorContains(t, err, "signature")
+
+	// statuses of the auth claim and of the claim are at different states
+	cred = credential(t, issuer, map[string]any{
+		"type":       verifiable.BJJSignatureProofType,
+		"issuerData": data,
+		"coreClaim":  claimHex,
+		"signature":  hex.EncodeToString(comp[:]),
+	})
+	authStatus := revocationStatus(t, issuer, issuer.AuthClaim)
+	issuer.AddClaim(t, claim)
+	_, err = Import(cred, verifiable.BJJSignatureProofType, Status{
+		Claim:     revocationStatus(t, issuer, claim),
+		AuthClaim: authStatus,
+	})
+	require.ErrorContains(t, err, "differs from claim revocation status state")
+
+	// non-revocation proof of other nonce
+	_, err = Import(cred, verifiable.BJJSignatureProofType, Status{
+		Claim:     revocationStatus(t, issuer, claim),
+		AuthClaim: revocationStatus(t, issuer, claim),
+	})
+	require.ErrorContains(t, err, "auth claim revocation status: mtp doesn't match revocation tree root")
+}
+
+func Test_ImportMtp(t *testing.T) {
+	user := utils.NewIdentity(t, userPK)
+	issuer := utils.NewIdentity(t, issuerPK, utils.WithFillers(5))
+	claim := utils.DefaultUserClaim(t, user.ID, nil)
+	issuer.AddClaim(t, claim)
+
+	mtp, _ := issuer.ClaimMTPRaw(t, claim)
+	claimHex, err := claim.Hex()
+	require.NoError(t, err)
+	cred := credential(t, issuer, map[string]any{
+		"type":       verifiable.Iden3SparseMerkleTreeProofType,
+		"issuerData": issuerData(t, issuer),
+		"coreClaim":  claimHex,
+		"mtp":        mtp,
+	})
+
+	status := Status{Claim: revocationStatus(t, issuer, claim)}
+	in, err := Import(cred, verifiable.Iden3SparseMerkleTreeProofType, status)
+	require.NoError(t, err)
+
+	issuerClaimMtp, _ := issuer.ClaimMTP(t, claim)
+	require.Equal(t, "2", in.ProofType)
+	require.Equal(t, claim, in.IssuerClaim)
+	require.Equal(t, issuerClaimMtp, in.IssuerClaimMtp)
+	require.Equal(t, issuer.State(t).String(), in.IssuerClaimIdenState)
+	require.Equal(t, issuer.Clt.Root(), in.IssuerClaimClaimsTreeRoot)
+	require.Equal(t, issuer.Rot.Root(), in.IssuerClaimRootsTreeRoot)
+	require.Equal(t, "0", in.IssuerAuthState)
+	require.Equal(t, "0", in.IssuerClaimSignatureS)
+
+	// credential has no signature proof
+	_, err = Import(cred, verifiable.BJJSignatureProofType, status)
+	require.ErrorContains(t, err, "no BJJSignature2021 proof")
+
+	// state of the status must be the hash of the roots
+	status.Claim.Issuer.State = hexHash(big.NewInt(1))
+	_, err = Import(cred, verifiable.Iden3SparseMerkleTreeProofType, status)
+	require.ErrorContains(t, err, "not the hash of the roots")
+
+	// proof of other claim
+	status = Status{Claim: revocationStatus(t, issuer, claim)}
+	cred.Proof[0].(*verifiable.Iden3SparseMerkleTreeProof).MTP, _ = issuer.ClaimMTPRaw(t, issuer.AuthClaim)
+	_, err = Import(cred, verifiable.Iden3SparseMerkleTreeProofType, status)
+	require.ErrorContains(t, err, "claim: mtp doesn't prove inclusion")
+
+	// non-revocation proof of other nonce
+	cred.Proof[0].(*verifiable.Iden3SparseMerkleTreeProof).MTP = mtp
+	status.Claim.MTP = revocationStatus(t, issuer, issuer.AuthClaim).MTP
+	_, err = Import(cred, verifiable.Iden3SparseMerkleTreeProofType, status)
+	require.ErrorContains(t, err, "claim revocation status: mtp doesn't match revocation tree root")
+}
+
+// Test_ImportIssuerNode imports credential in the format of issuer node with
+// both proofs and revocation statuses of the credentialStatus of the
+// credential and of the auth claim. The signature proof is made at genesis
+// state of the issuer, the statuses are at the state the claim is published.
+func Test_ImportIssuerNode(t *testing.T) {
+	data, err := os.ReadFile("testdata/issuer_node_credential.json")
+	require.NoError(t, err)
+	cred, err := Parse(data)
+	require.NoError(t, err)
+	var status Status
+	readJSON(t, "testdata/issuer_node_claim_status.json", &status.Claim)
+	readJSON(t, "testdata/issuer_node_auth_claim_status.json", &status.AuthClaim)
+
+	did, err := w3c.ParseDID(cred.Issuer)
+	require.NoError(t, err)
+	issuerID, err := core.IDFromDID(*did)
+	require.NoError(t, err)
+
+	sig, err := Import(cred, verifiable.BJJSignatureProofType, status)
+	require.NoError(t, err)
+	require.Equal(t, issuerID.BigInt().String(), sig.IssuerID)
+	require.Equal(t, uint64(cred.CredentialStatus.(map[string]any)["revocationNonce"].(float64)),
+		sig.IssuerClaim.GetRevocationNonce())
+	require.NotEqual(t, sig.IssuerAuthState, sig.IssuerClaimNonRevState)
+
+	mtp, err := Import(cred, verifiable.Iden3SparseMerkleTreeProofType, status)
+	require.NoError(t, err)
+	require.Equal(t, sig.IssuerClaim, mtp.IssuerClaim)
+	require.Equal(t, sig.IssuerClaimNonRevState, mtp.IssuerClaimIdenState)
+	require.Equal(t, sig.IssuerClaimNonRevMtp, mtp.IssuerClaimNonRevMtp)
+}
+
+func readJSON(t *testing.T, name string, v any) {
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, v))
+}
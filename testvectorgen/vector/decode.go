@@ -0,0 +1,157 @@
+package vector
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	core "github.com/iden3/go-iden3-core/v2"
+)
+
+// Kind of the signal detected from its name and value
+type Kind int
+
+const (
+	KindScalar Kind = iota
+	KindID
+	KindState
+	KindClaim
+	KindSiblings
+	KindArray
+)
+
+// claimSlotLabels describes the meaning of each of the 8 claim slots
+var claimSlotLabels = [8]string{
+	"i_0 (schema, flags)",
+	"i_1 (index ID)",
+	"i_2 (index slot A)",
+	"i_3 (index slot B)",
+	"v_0 (revocation nonce, version, expiration)",
+	"v_1 (value ID)",
+	"v_2 (value slot A)",
+	"v_3 (value slot B)",
+}
+
+var treeRootSuffixes = []string{"ClaimsTreeRoot", "RevTreeRoot", "RootsTreeRoot"}
+
+// KindOf detects kind of the signal by its name and decoded value
+func KindOf(name string, v any) Kind {
+	list, isList := v.([]any)
+	if isList {
+		switch {
+		case strings.HasSuffix(strings.ToLower(name), "claim") && len(list) == 8:
+			return KindClaim
+		case strings.HasSuffix(name, "Mtp"):
+			return KindSiblings
+		default:
+			return KindArray
+		}
+	}
+
+	s, _ := v.(string)
+	switch {
+	case strings.HasSuffix(strings.ToLower(name), "state"):
+		return KindState
+	case strings.HasSuffix(name, "ID"):
+		if _, ok := DecodeDID(s); ok {
+			return KindID
+		}
+	}
+	return KindScalar
+}
+
+// DecodeDID returns DID of the identifier encoded as decimal string.
+// Returns false if value is not a valid identifier.
+func DecodeDID(s string) (string, bool) {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok || i.Sign() == 0 {
+		return "", false
+	}
+	id, err := core.IDFromInt(i)
+	if err != nil {
+		return "", false
+	}
+	did, err := core.ParseDIDFromID(id)
+	if err != nil {
+		return "", false
+	}
+	return did.String(), true
+}
+
+// StateRootNames returns names of the claims, revocation and roots tree roots
+// signals that compose the state signal. Returns nil if they were not found.
+func (td *TestData) StateRootNames(stateName string) []string {
+	prefix := strings.TrimSuffix(strings.TrimSuffix(stateName, "State"), "state")
+	prefix = strings.TrimSuffix(prefix, "Iden")
+
+	candidates := []string{prefix}
+	if p := strings.TrimSuffix(prefix, "User"); p != prefix {
+		candidates = append(candidates, p)
+		if p == "old" {
+			candidates = append(candidates, "")
+		}
+	}
+
+	for _, c := range candidates {
+		names := make([]string, 0, len(treeRootSuffixes))
+		for _, suffix := range treeRootSuffixes {
+			name := c + suffix
+			if c == "" {
+				name = strings.ToLower(suffix[:1]) + suffix[1:]
+			}
+			if _, ok := td.Inputs[name]; !ok {
+				break
+			}
+			names = append(names, name)
+		}
+		if len(names) == len(treeRootSuffixes) {
+			return names
+		}
+	}
+	return nil
+}
+
+// leaf is a single field element of the signal with a path inside the signal
+type leaf struct {
+	path  string
+	label string
+	value string
+}
+
+func leaves(name string, v any) []leaf {
+	kind := KindOf(name, v)
+	list, isList := v.([]any)
+	if !isList {
+		return []leaf{{value: v.(string)}}
+	}
+
+	var res []leaf
+	for i, item := range list {
+		path := fmt.Sprintf("[%d]", i)
+		switch kind {
+		case KindClaim:
+			res = append(res, leaf{path: path, label: claimSlotLabels[i], value: fmt.Sprint(item)})
+			continue
+		case KindSiblings:
+			if _, nested := item.([]any); !nested {
+				res = append(res, leaf{path: path, label: fmt.Sprintf("sibling %d", i), value: fmt.Sprint(item)})
+				continue
+			}
+		}
+		for _, l := range leaves(name, item) {
+			l.path = path + l.path
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+// describe returns human-readable view of the scalar signal value
+func describe(kind Kind, value string) string {
+	if kind == KindID {
+		if did, ok := DecodeDID(value); ok {
+			return fmt.Sprintf("%s (%s)", value, did)
+		}
+	}
+	return value
+}
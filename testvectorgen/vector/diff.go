@@ -0,0 +1,168 @@
+package vector
+
+import (
+	"fmt"
+	"io"
+)
+
+// Absent is shown in place of the value of a signal missing in one of the vectors
+const Absent = "<absent>"
+
+// Difference is a single field element that differs between two test vectors
+type Difference struct {
+	Section Section
+	Signal  string
+	Path    string // index inside array signals, e.g. [3] or [1][31]
+	Label   string // meaning of the element, e.g. claim slot or sibling number
+	Kind    Kind
+	Old     string
+	New     string
+	// Public is true if the signal is a public signal of the circuit, so
+	// the difference changes public.json and on-chain verification data.
+	Public bool
+	// Notes explain the difference, e.g. which tree roots changed the state
+	Notes []string
+}
+
+// Diff compares two test vectors signal by signal
+func Diff(a, b *TestData) ([]Difference, error) {
+	var diffs []Difference
+	if a.Desc != b.Desc {
+		diffs = append(diffs, Difference{Signal: "desc", Old: a.Desc, New: b.Desc})
+	}
+
+	for _, section := range []Section{Inputs, Outputs} {
+		sigA, sigB := a.signals(section), b.signals(section)
+		for _, name := range sortedNames(sigA, sigB) {
+			d, err := diffSignal(a, b, section, name)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, d...)
+		}
+	}
+	return diffs, nil
+}
+
+func diffSignal(a, b *TestData, section Section, name string) ([]Difference, error) {
+	va, okA, err := a.Signal(section, name)
+	if err != nil {
+		return nil, err
+	}
+	vb, okB, err := b.Signal(section, name)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := KindOf(name, va)
+	if !okA {
+		kind = KindOf(name, vb)
+	}
+
+	public := section == Outputs || isPublicInput(a, name) || isPublicInput(b, name)
+
+	var la, lb []leaf
+	if okA {
+		la = leaves(name, va)
+	}
+	if okB {
+		lb = leaves(name, vb)
+	}
+
+	byPath := make(map[string]leaf, len(lb))
+	for _, l := range lb {
+		byPath[l.path] = l
+	}
+	seen := make(map[string]bool, len(la))
+
+	var diffs []Difference
+	add := func(l leaf, oldV, newV string) {
+		d := Difference{
+			Section: section,
+			Signal:  name,
+			Path:    l.path,
+			Label:   l.label,
+			Kind:    kind,
+			Old:     oldV,
+			New:     newV,
+			Public:  public,
+		}
+		if kind == KindState {
+			d.Notes = stateNotes(a, b, name)
+		}
+		diffs = append(diffs, d)
+	}
+
+	for _, l := range la {
+		seen[l.path] = true
+		other, ok := byPath[l.path]
+		switch {
+		case !ok:
+			add(l, describe(kind, l.value), Absent)
+		case other.value != l.value:
+			add(l, describe(kind, l.value), describe(kind, other.value))
+		}
+	}
+	for _, l := range lb {
+		if !seen[l.path] {
+			add(l, Absent, describe(kind, l.value))
+		}
+	}
+	return diffs, nil
+}
+
+// isPublicInput returns true if input signal is also present in expected
+// outputs. Generators put public inputs to expOut to check them in tests.
+func isPublicInput(td *TestData, name string) bool {
+	_, ok := td.ExpOut[name]
+	return ok
+}
+
+func stateNotes(a, b *TestData, name string) []string {
+	names := a.StateRootNames(name)
+	if names == nil {
+		names = b.StateRootNames(name)
+	}
+
+	var notes []string
+	for _, rootName := range names {
+		ra, _, _ := a.Signal(Inputs, rootName)
+		rb, _, _ := b.Signal(Inputs, rootName)
+		if fmt.Sprint(ra) != fmt.Sprint(rb) {
+			notes = append(notes, fmt.Sprintf("%s: %v -> %v", rootName, ra, rb))
+		} else {
+			notes = append(notes, fmt.Sprintf("%s: unchanged", rootName))
+		}
+	}
+	return notes
+}
+
+// WriteDiff writes differences in human-readable form
+func WriteDiff(w io.Writer, diffs []Difference) error {
+	public := 0
+	for _, d := range diffs {
+		name := d.Signal
+		if d.Section != "" {
+			name = fmt.Sprintf("%s.%s%s", d.Section, d.Signal, d.Path)
+		}
+		if d.Label != "" {
+			name += " " + d.Label
+		}
+		if d.Public {
+			name += " [public]"
+			public++
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\n  - %s\n  + %s\n", name, d.Old, d.New); err != nil {
+			return err
+		}
+		for _, n := range d.Notes {
+			if _, err := fmt.Fprintf(w, "    %s\n", n); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d differences, %d in public signals\n", len(diffs), public)
+	return err
+}
@@ -0,0 +1,134 @@
+package vector
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"test/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userPK   = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69e"
+	issuerPK = "28156abe7fe2fd433dc9df969286b96666489bac508612d0e16593e944c4f69d"
+)
+
+func testVector(t *testing.T, userPK string, claimValue int64) *TestData {
+	user := utils.NewIdentity(t, userPK)
+	issuer := utils.NewIdentity(t, issuerPK)
+	claim := utils.DefaultUserClaim(t, user.ID, big.NewInt(claimValue))
+	issuer.AddClaim(t, claim)
+	mtp, _ := issuer.ClaimMTP(t, claim)
+
+	data := map[string]any{
+		"desc": "test",
+		"inputs": map[string]any{
+			"userGenesisID":             user.ID.BigInt().String(),
+			"requestID":                 "23",
+			"operator":                  1,
+			"issuerClaim":               claim,
+			"issuerClaimMtp":            mtp,
+			"issuerClaimIdenState":      issuer.State(t).String(),
+			"issuerClaimClaimsTreeRoot": issuer.Clt.Root(),
+			"issuerClaimRevTreeRoot":    issuer.Ret.Root(),
+			"issuerClaimRootsTreeRoot":  issuer.Rot.Root(),
+		},
+		"expOut": map[string]any{
+			"userID":    user.ID.BigInt().String(),
+			"requestID": "23",
+			"operator":  1,
+		},
+	}
+	b, err := json.Marshal(data)
+	require.NoError(t, err)
+	td, err := Parse(b)
+	require.NoError(t, err)
+	return td
+}
+
+func Test_DiffEqual(t *testing.T) {
+	a := testVector(t, userPK, 10)
+	b := testVector(t, userPK, 10)
+
+	diffs, err := Diff(a, b)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
+
+func Test_DiffClaimSlotAndState(t *testing.T) {
+	a := testVector(t, userPK, 10)
+	b := testVector(t, userPK, 11)
+
+	diffs, err := Diff(a, b)
+	require.NoError(t, err)
+
+	bySignal := map[string][]Difference{}
+	for _, d := range diffs {
+		bySignal[d.Signal] = append(bySignal[d.Signal], d)
+	}
+
+	require.Len(t, bySignal["issuerClaim"], 1)
+	require.Equal(t, "[2]", bySignal["issuerClaim"][0].Path)
+	require.Equal(t, "i_2 (index slot A)", bySignal["issuerClaim"][0].Label)
+	require.Equal(t, "10", bySignal["issuerClaim"][0].Old)
+	require.Equal(t, "11", bySignal["issuerClaim"][0].New)
+	require.False(t, bySignal["issuerClaim"][0].Public)
+
+	require.Len(t, bySignal["issuerClaimIdenState"], 1)
+	state := bySignal["issuerClaimIdenState"][0]
+	require.Equal(t, KindState, state.Kind)
+	require.Len(t, state.Notes, 3)
+	require.True(t, strings.HasPrefix(state.Notes[0], "issuerClaimClaimsTreeRoot: "))
+	require.NotContains(t, state.Notes[0], "unchanged")
+	require.Equal(t, "issuerClaimRevTreeRoot: unchanged", state.Notes[1])
+
+	for _, d := range bySignal["issuerClaimMtp"] {
+		require.Equal(t, KindSiblings, d.Kind)
+		require.True(t, strings.HasPrefix(d.Label, "sibling "))
+	}
+	require.Empty(t, bySignal["userID"])
+}
+
+func Test_DiffPublicID(t *testing.T) {
+	a := testVector(t, userPK, 10)
+	b := testVector(t, issuerPK, 10)
+
+	diffs, err := Diff(a, b)
+	require.NoError(t, err)
+
+	var found bool
+	for _, d := range diffs {
+		if d.Section == Outputs && d.Signal == "userID" {
+			found = true
+			require.True(t, d.Public)
+			require.Equal(t, KindID, d.Kind)
+			require.Contains(t, d.Old, "did:iden3:polygon:mumbai:")
+			require.Contains(t, d.New, "did:iden3:polygon:mumbai:")
+		}
+		if d.Section == Inputs && d.Signal == "userGenesisID" {
+			require.False(t, d.Public)
+		}
+	}
+	require.True(t, found)
+
+	var sb strings.Builder
+	require.NoError(t, WriteDiff(&sb, diffs))
+	require.Contains(t, sb.String(), "expOut.userID [public]")
+}
+
+func Test_DiffAbsentSignal(t *testing.T) {
+	a := testVector(t, userPK, 10)
+	b := testVector(t, userPK, 10)
+	delete(b.Inputs, "operator")
+
+	diffs, err := Diff(a, b)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	require.Equal(t, "operator", diffs[0].Signal)
+	require.Equal(t, "1", diffs[0].Old)
+	require.Equal(t, Absent, diffs[0].New)
+	require.True(t, diffs[0].Public)
+}
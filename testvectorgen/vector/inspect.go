@@ -0,0 +1,491 @@
+package vector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"test/utils"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+)
+
+var operatorNames = map[int]string{
+	utils.NOOP:        "NOOP",
+	utils.EQ:          "EQ",
+	utils.LT:          "LT",
+	utils.GT:          "GT",
+	utils.IN:          "IN",
+	utils.NIN:         "NIN",
+	utils.NE:          "NE",
+	utils.LTE:         "LTE",
+	utils.GTE:         "GTE",
+	utils.BETWEEN:     "BETWEEN",
+	utils.NOT_BETWEEN: "NOT_BETWEEN",
+	utils.EXISTS:      "EXISTS",
+	utils.SD:          "SD",
+}
+
+// proofRoots maps proof signals to the root signals they are verified against
+var proofRoots = map[string][]string{
+	"issuerClaimMtp":           {"issuerClaimClaimsTreeRoot"},
+	"issuerClaimNonRevMtp":     {"issuerClaimNonRevRevTreeRoot"},
+	"issuerAuthClaimMtp":       {"issuerAuthClaimsTreeRoot"},
+	"issuerAuthClaimNonRevMtp": {"issuerAuthRevTreeRoot"},
+	"authClaimIncMtp":          {"userClaimsTreeRoot", "claimsTreeRoot"},
+	"authClaimMtp":             {"claimsTreeRoot"},
+	"newAuthClaimMtp":          {"newClaimsTreeRoot"},
+	"authClaimNonRevMtp":       {"userRevTreeRoot", "revTreeRoot"},
+	"gistMtp":                  {"gistRoot"},
+}
+
+// OperatorName returns name of the query operator
+func OperatorName(op int) string {
+	if name, ok := operatorNames[op]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// Inspection is a human-readable decoding of the test vector
+type Inspection struct {
+	Desc    string
+	IDs     []IDInfo
+	Claims  []ClaimInfo
+	Proofs  []ProofInfo
+	Queries []QueryInfo
+}
+
+// IDInfo describes identifier signal
+type IDInfo struct {
+	Section Section
+	Signal  string
+	Value   string
+	DID     string
+}
+
+// ClaimInfo describes claim signal
+type ClaimInfo struct {
+	Signal            string
+	Schema            string
+	SubjectPosition   string
+	Subject           string
+	MerklizedPosition string
+	MerklizedRoot     string
+	Expiration        *time.Time
+	Updatable         bool
+	Version           uint32
+	RevocationNonce   uint64
+}
+
+// ProofInfo describes merkle tree proof signal
+type ProofInfo struct {
+	Signal    string
+	Root      string
+	RootValue string
+	Depth     int // index of the deepest non-zero sibling + 1
+	Levels    int
+	Aux       string
+}
+
+// QueryInfo describes query signals
+type QueryInfo struct {
+	Index        int // query index for multi query circuits
+	Operator     int
+	OperatorName string
+	SlotIndex    string
+	ClaimPathKey string
+	Values       []string
+}
+
+// Inspect decodes test vector signals
+func Inspect(td *TestData) (*Inspection, error) {
+	ins := &Inspection{Desc: td.Desc}
+
+	for _, section := range []Section{Inputs, Outputs} {
+		sig := td.signals(section)
+		for _, name := range sortedNames(sig, nil) {
+			v, _, err := td.Signal(section, name)
+			if err != nil {
+				return nil, err
+			}
+			if KindOf(name, v) != KindID {
+				continue
+			}
+			did, _ := DecodeDID(v.(string))
+			ins.IDs = append(ins.IDs, IDInfo{Section: section, Signal: name, Value: v.(string), DID: did})
+		}
+	}
+
+	claims := map[string]*core.Claim{}
+	for _, name := range sortedNames(td.Inputs, nil) {
+		v, _, err := td.Signal(Inputs, name)
+		if err != nil {
+			return nil, err
+		}
+		switch KindOf(name, v) {
+		case KindClaim:
+			var claim core.Claim
+			if err := json.Unmarshal(td.Inputs[name], &claim); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if isZeroClaim(&claim) {
+				continue
+			}
+			info, err := inspectClaim(name, &claim)
+			if err != nil {
+				return nil, err
+			}
+			claims[name] = &claim
+			ins.Claims = append(ins.Claims, *info)
+		}
+	}
+
+	for _, name := range sortedNames(td.Inputs, nil) {
+		v, _, _ := td.Signal(Inputs, name)
+		if KindOf(name, v) != KindSiblings {
+			continue
+		}
+		proofs, err := inspectProof(td, name, v.([]any), claims["issuerClaim"])
+		if err != nil {
+			return nil, err
+		}
+		ins.Proofs = append(ins.Proofs, proofs...)
+	}
+
+	queries, err := inspectQueries(td, claims["issuerClaim"])
+	if err != nil {
+		return nil, err
+	}
+	ins.Queries = queries
+
+	return ins, nil
+}
+
+func isZeroClaim(c *core.Claim) bool {
+	for _, v := range c.RawSlotsAsInts() {
+		if v.Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func inspectClaim(name string, claim *core.Claim) (*ClaimInfo, error) {
+	schema := claim.GetSchemaHash()
+	info := &ClaimInfo{
+		Signal:          name,
+		Schema:          fmt.Sprintf("%x (%s)", schema[:], schema.BigInt()),
+		Updatable:       claim.GetFlagUpdatable(),
+		Version:         claim.GetVersion(),
+		RevocationNonce: claim.GetRevocationNonce(),
+	}
+
+	idPos, err := claim.GetIDPosition()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	switch idPos {
+	case core.IDPositionNone:
+		info.SubjectPosition = "none (self)"
+	case core.IDPositionIndex:
+		info.SubjectPosition = "index"
+	case core.IDPositionValue:
+		info.SubjectPosition = "value"
+	}
+	if idPos != core.IDPositionNone {
+		id, err := claim.GetID()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		info.Subject = id.BigInt().String()
+		if did, ok := DecodeDID(info.Subject); ok {
+			info.Subject = did
+		}
+	}
+
+	mPos, err := claim.GetMerklizedPosition()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	switch mPos {
+	case core.MerklizedRootPositionNone:
+		info.MerklizedPosition = "none"
+	case core.MerklizedRootPositionIndex:
+		info.MerklizedPosition = "index"
+	case core.MerklizedRootPositionValue:
+		info.MerklizedPosition = "value"
+	}
+	if mPos != core.MerklizedRootPositionNone {
+		root, err := claim.GetMerklizedRoot()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		info.MerklizedRoot = root.String()
+	}
+
+	if exp, ok := claim.GetExpirationDate(); ok {
+		exp = exp.UTC()
+		info.Expiration = &exp
+	}
+	return info, nil
+}
+
+func inspectProof(td *TestData, name string, siblings []any, claim *core.Claim) ([]ProofInfo, error) {
+	if len(siblings) > 0 {
+		if _, nested := siblings[0].([]any); nested {
+			var res []ProofInfo
+			for i, s := range siblings {
+				proofs, err := inspectProof(td, name, s.([]any), claim)
+				if err != nil {
+					return nil, err
+				}
+				for _, p := range proofs {
+					p.Signal = fmt.Sprintf("%s[%d]", name, i)
+					p.Aux = auxCase(td, name, i)
+					res = append(res, p)
+				}
+			}
+			return res, nil
+		}
+	}
+
+	p := ProofInfo{Signal: name, Levels: len(siblings), Aux: auxCase(td, name, -1)}
+	for i, s := range siblings {
+		if s.(string) != "0" {
+			p.Depth = i + 1
+		}
+	}
+
+	for _, root := range proofRoots[name] {
+		if v, ok, _ := td.Signal(Inputs, root); ok {
+			p.Root = root
+			p.RootValue = fmt.Sprint(v)
+			break
+		}
+	}
+	if name == "claimPathMtp" && claim != nil {
+		if root, err := claim.GetMerklizedRoot(); err == nil {
+			p.Root = "issuerClaim merklized root"
+			p.RootValue = root.String()
+		}
+	}
+	return []ProofInfo{p}, nil
+}
+
+// auxCase returns which SMTVerifier branch the proof auxiliary inputs select
+func auxCase(td *TestData, proofName string, idx int) string {
+	noAux, ok, _ := td.Signal(Inputs, proofName+"NoAux")
+	if !ok {
+		return ""
+	}
+	auxHi, _, _ := td.Signal(Inputs, proofName+"AuxHi")
+	if idx >= 0 {
+		noAux = itemAt(noAux, idx)
+		auxHi = itemAt(auxHi, idx)
+	}
+	switch {
+	case noAux == "1":
+		return "non-inclusion, empty leaf"
+	case auxHi != nil && auxHi != "0":
+		return "non-inclusion, aux node"
+	default:
+		return "inclusion"
+	}
+}
+
+func itemAt(v any, idx int) any {
+	list, ok := v.([]any)
+	if !ok || idx >= len(list) {
+		return nil
+	}
+	return list[idx]
+}
+
+func inspectQueries(td *TestData, claim *core.Claim) ([]QueryInfo, error) {
+	op, ok, err := td.Signal(Inputs, "operator")
+	if err != nil || !ok {
+		return nil, err
+	}
+	slot, _, _ := td.Signal(Inputs, "slotIndex")
+	key, _, _ := td.Signal(Inputs, "claimPathKey")
+	values, _, _ := td.Signal(Inputs, "value")
+	size, _, _ := td.Signal(Inputs, "valueArraySize")
+
+	if ops, multi := op.([]any); multi {
+		var res []QueryInfo
+		for i := range ops {
+			q, err := inspectQuery(i, ops[i], itemAt(slot, i), itemAt(key, i), itemAt(values, i), itemAt(size, i))
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, *q)
+		}
+		return res, nil
+	}
+
+	q, err := inspectQuery(0, op, slot, key, values, size)
+	if err != nil {
+		return nil, err
+	}
+	return []QueryInfo{*q}, nil
+}
+
+func inspectQuery(idx int, op, slot, key, values, size any) (*QueryInfo, error) {
+	operator, err := strconv.Atoi(fmt.Sprint(op))
+	if err != nil {
+		return nil, fmt.Errorf("invalid operator %v", op)
+	}
+	q := &QueryInfo{
+		Index:        idx,
+		Operator:     operator,
+		OperatorName: OperatorName(operator),
+		SlotIndex:    fmt.Sprint(slot),
+		ClaimPathKey: fmt.Sprint(key),
+	}
+
+	list, _ := values.([]any)
+	n := len(list)
+	if size != nil {
+		if s, err := strconv.Atoi(fmt.Sprint(size)); err == nil && s <= n {
+			n = s
+		}
+	}
+	for _, v := range list[:n] {
+		q.Values = append(q.Values, DescribeValue(fmt.Sprint(v)))
+	}
+	return q, nil
+}
+
+// DescribeValue returns query value with known encodings decoded:
+//   - identifiers as DIDs
+//   - negative numbers stored as p-x
+//   - booleans hashed by merklize
+//   - xsd:dateTime values, which merklize encodes as unix nanoseconds, of
+//     whole seconds between 1900 and 2200
+//   - integer dates in yyyymmdd format
+//
+// Strings and other values merklize hashes can't be decoded and are returned
+// as is.
+func DescribeValue(v string) string {
+	i, ok := new(big.Int).SetString(v, 10)
+	if !ok {
+		return v
+	}
+
+	if did, ok := DecodeDID(v); ok {
+		return fmt.Sprintf("%s (%s)", v, did)
+	}
+
+	for _, b := range []bool{true, false} {
+		h, err := merklize.HashValue("http://www.w3.org/2001/XMLSchema#boolean", b)
+		if err == nil && h.Cmp(i) == 0 {
+			return fmt.Sprintf("%s (%t)", v, b)
+		}
+	}
+
+	half := new(big.Int).Rsh(constants.Q, 1)
+	if i.Cmp(half) > 0 && i.Cmp(constants.Q) < 0 {
+		neg := new(big.Int).Sub(i, constants.Q)
+		if date, ok := decodeDateTime(neg); ok {
+			return fmt.Sprintf("%s (%s, %s)", v, neg, date)
+		}
+		return fmt.Sprintf("%s (%s)", v, neg)
+	}
+
+	if date, ok := decodeDateTime(i); ok {
+		return fmt.Sprintf("%s (%s)", v, date)
+	}
+	if date, err := time.Parse("20060102", v); err == nil && date.Year() >= 1900 && date.Year() < 2200 {
+		return fmt.Sprintf("%s (%s)", v, date.Format("2006-01-02"))
+	}
+	return v
+}
+
+var (
+	minDateTime = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxDateTime = time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+	// dateTimes closer to unix epoch are more likely small numbers
+	minDateTimeDistance = big.NewInt(int64(365 * 24 * time.Hour))
+)
+
+// decodeDateTime returns xsd:dateTime encoded by merklize as unix nanoseconds,
+// date only for midnight
+func decodeDateTime(ns *big.Int) (string, bool) {
+	if !ns.IsInt64() || new(big.Int).Abs(ns).Cmp(minDateTimeDistance) < 0 ||
+		new(big.Int).Rem(ns, big.NewInt(int64(time.Second))).Sign() != 0 {
+		return "", false
+	}
+	t := time.Unix(0, ns.Int64()).UTC()
+	if t.Before(minDateTime) || !t.Before(maxDateTime) {
+		return "", false
+	}
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02"), true
+	}
+	return t.Format(time.RFC3339), true
+}
+
+// WriteInspection writes inspection in human-readable form
+func WriteInspection(w io.Writer, ins *Inspection) error {
+	var err error
+	p := func(format string, a ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, a...)
+		}
+	}
+
+	p("%s\n", ins.Desc)
+
+	p("\nIDs:\n")
+	for _, id := range ins.IDs {
+		p("  %s.%s: %s\n", id.Section, id.Signal, id.DID)
+	}
+
+	for _, c := range ins.Claims {
+		p("\nClaim %s:\n", c.Signal)
+		p("  schema:             %s\n", c.Schema)
+		p("  subject position:   %s\n", c.SubjectPosition)
+		if c.Subject != "" {
+			p("  subject:            %s\n", c.Subject)
+		}
+		p("  merklized position: %s\n", c.MerklizedPosition)
+		if c.MerklizedRoot != "" {
+			p("  merklized root:     %s\n", c.MerklizedRoot)
+		}
+		if c.Expiration != nil {
+			p("  expiration:         %s (%d)\n", c.Expiration.Format(time.RFC3339), c.Expiration.Unix())
+		} else {
+			p("  expiration:         none\n")
+		}
+		p("  updatable:          %t\n", c.Updatable)
+		p("  version:            %d\n", c.Version)
+		p("  revocation nonce:   %d\n", c.RevocationNonce)
+	}
+
+	p("\nProofs:\n")
+	for _, pr := range ins.Proofs {
+		p("  %s: depth %d/%d", pr.Signal, pr.Depth, pr.Levels)
+		if pr.Aux != "" {
+			p(", %s", pr.Aux)
+		}
+		if pr.Root != "" {
+			p(", root %s = %s", pr.Root, pr.RootValue)
+		}
+		p("\n")
+	}
+
+	if len(ins.Queries) > 0 {
+		p("\nQueries:\n")
+	}
+	for _, q := range ins.Queries {
+		p("  [%d] %s (%d), slotIndex %s, claimPathKey %s, values %v\n",
+			q.Index, q.OperatorName, q.Operator, q.SlotIndex, q.ClaimPathKey, q.Values)
+	}
+	return err
+}
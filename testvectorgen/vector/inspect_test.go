@@ -0,0 +1,78 @@
+package vector
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"test/utils"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Inspect(t *testing.T) {
+	td := testVector(t, userPK, 10)
+
+	ins, err := Inspect(td)
+	require.NoError(t, err)
+
+	user := utils.NewIdentity(t, userPK)
+	require.Len(t, ins.IDs, 2)
+	require.Equal(t, "userGenesisID", ins.IDs[0].Signal)
+	require.Equal(t, "did:iden3:polygon:mumbai:"+user.ID.String(), ins.IDs[0].DID)
+
+	require.Len(t, ins.Claims, 1)
+	claim := ins.Claims[0]
+	require.Equal(t, "issuerClaim", claim.Signal)
+	require.True(t, strings.HasPrefix(claim.Schema, "ce6bb12c96bfd1544c02c289c6b4b987"))
+	require.Equal(t, "index", claim.SubjectPosition)
+	require.Equal(t, "did:iden3:polygon:mumbai:"+user.ID.String(), claim.Subject)
+	require.Equal(t, "none", claim.MerklizedPosition)
+	require.NotNil(t, claim.Expiration)
+	require.Equal(t, int64(1669884010), claim.Expiration.Unix())
+	require.False(t, claim.Updatable)
+	require.Equal(t, uint64(1), claim.RevocationNonce)
+
+	require.Len(t, ins.Proofs, 1)
+	require.Equal(t, "issuerClaimMtp", ins.Proofs[0].Signal)
+	require.Equal(t, "issuerClaimClaimsTreeRoot", ins.Proofs[0].Root)
+	require.Positive(t, ins.Proofs[0].Depth)
+
+	require.Len(t, ins.Queries, 1)
+	require.Equal(t, "EQ", ins.Queries[0].OperatorName)
+
+	var sb strings.Builder
+	require.NoError(t, WriteInspection(&sb, ins))
+	require.Contains(t, sb.String(), "Claim issuerClaim:")
+}
+
+func Test_DescribeValue(t *testing.T) {
+	require.Equal(t, "10", DescribeValue("10"))
+	require.Equal(t,
+		"21888242871839275222246405745257275088548364400416034343698204186575808495616 (-1)",
+		DescribeValue("21888242871839275222246405745257275088548364400416034343698204186575808495616"))
+
+	user := utils.NewIdentity(t, userPK)
+	id := user.ID.BigInt().String()
+	require.Equal(t, id+" (did:iden3:polygon:mumbai:"+user.ID.String()+")", DescribeValue(id))
+
+	dateTime := func(tm time.Time) string {
+		v, err := utils.EncodeValue(ld.XSDNS+"dateTime", tm)
+		require.NoError(t, err)
+		return v.String()
+	}
+	v := dateTime(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, v+" (2001-01-01)", DescribeValue(v))
+	v = dateTime(time.Date(2023, 10, 5, 12, 30, 0, 0, time.UTC))
+	require.Equal(t, v+" (2023-10-05T12:30:00Z)", DescribeValue(v))
+	v = dateTime(time.Date(1960, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, v+" (-302486400000000000, 1960-06-01)", DescribeValue(v))
+
+	require.Equal(t, "20010101 (2001-01-01)", DescribeValue("20010101"))
+	require.Equal(t, "20011301", DescribeValue("20011301"))
+
+	// hashed strings are not decoded
+	v = utils.QueryValues(t, ld.XSDString, "KYCAgeCredential")[0]
+	require.Equal(t, v, DescribeValue(v))
+}
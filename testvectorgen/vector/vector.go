@@ -0,0 +1,129 @@
+// Package vector loads generated test vectors without knowing the circuit
+// specific Inputs/Outputs structs and decodes their signals into typed views.
+package vector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// TestData is a circuit agnostic representation of a test vector file
+// written by utils.SaveTestVector.
+type TestData struct {
+	Desc   string                     `json:"desc"`
+	Inputs map[string]json.RawMessage `json:"inputs"`
+	ExpOut map[string]json.RawMessage `json:"expOut"`
+}
+
+// Load reads test vector from file
+func Load(fileName string) (*TestData, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes test vector from JSON
+func Parse(data []byte) (*TestData, error) {
+	var td TestData
+	if err := json.Unmarshal(data, &td); err != nil {
+		return nil, fmt.Errorf("invalid test vector: %w", err)
+	}
+	return &td, nil
+}
+
+// Signal returns decoded value of the signal from inputs or expected outputs
+func (td *TestData) Signal(section Section, name string) (any, bool, error) {
+	var raw json.RawMessage
+	var ok bool
+	switch section {
+	case Inputs:
+		raw, ok = td.Inputs[name]
+	case Outputs:
+		raw, ok = td.ExpOut[name]
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	v, err := decodeRaw(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s.%s: %w", section, name, err)
+	}
+	return v, true, nil
+}
+
+// Section identifies part of the test vector the signal belongs to
+type Section string
+
+const (
+	Inputs  Section = "inputs"
+	Outputs Section = "expOut"
+)
+
+func (td *TestData) signals(section Section) map[string]json.RawMessage {
+	if section == Inputs {
+		return td.Inputs
+	}
+	return td.ExpOut
+}
+
+// decodeRaw converts JSON value to string scalars or nested []any of strings.
+// Numbers and strings are both represented as decimal strings because
+// generators are not consistent in how they marshal integer signals.
+func decodeRaw(raw json.RawMessage) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return normalize(v)
+}
+
+func normalize(v any) (any, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	case bool:
+		if val {
+			return "1", nil
+		}
+		return "0", nil
+	case []any:
+		res := make([]any, len(val))
+		for i := range val {
+			n, err := normalize(val[i])
+			if err != nil {
+				return nil, err
+			}
+			res[i] = n
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("unsupported signal value type %T", v)
+	}
+}
+
+func sortedNames(a, b map[string]json.RawMessage) []string {
+	set := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		set[k] = struct{}{}
+	}
+	for k := range b {
+		set[k] = struct{}{}
+	}
+	names := make([]string, 0, len(set))
+	for k := range set {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}